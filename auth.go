@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// Permission is the access level a service requires, and the level a
+// token grants.
+type Permission int
+
+const (
+	// PermissionNone is required by services open to everyone, such as
+	// health checks.
+	PermissionNone Permission = iota
+
+	// PermissionView allows watching the terminal without changing it.
+	PermissionView
+
+	// PermissionControl allows everything, including input.
+	PermissionControl
+)
+
+// Auth maps tokens to permissions. A nil Auth or one without tokens grants
+// full control to every request.
+type Auth struct {
+	tokens []authToken
+}
+
+type authToken struct {
+	token []byte
+	perm  Permission
+}
+
+func NewAuth(control, view []string) *Auth {
+	a := &Auth{}
+	for _, t := range control {
+		a.tokens = append(a.tokens, authToken{token: []byte(t), perm: PermissionControl})
+	}
+	for _, t := range view {
+		a.tokens = append(a.tokens, authToken{token: []byte(t), perm: PermissionView})
+	}
+	return a
+}
+
+// Check returns an error response if token does not grant need.
+func (a *Auth) Check(token string, need Permission) *ServiceResponse {
+	if a == nil || len(a.tokens) <= 0 || need == PermissionNone {
+		return nil
+	}
+
+	perm, ok := a.lookup(token)
+	if !ok {
+		return &ServiceResponse{
+			Code: http.StatusUnauthorized,
+			Body: []byte("invalid token"),
+		}
+	}
+	if perm < need {
+		return &ServiceResponse{
+			Code: http.StatusForbidden,
+			Body: []byte("permission denied"),
+		}
+	}
+	return nil
+}
+
+// Grant returns the permission granted by token. Every request has full
+// control if no tokens are configured.
+func (a *Auth) Grant(token string) Permission {
+	if a == nil || len(a.tokens) <= 0 {
+		return PermissionControl
+	}
+	perm, _ := a.lookup(token)
+	return perm
+}
+
+// lookup compares token against every known token in constant time, so
+// that the response time does not reveal partial matches.
+func (a *Auth) lookup(token string) (Permission, bool) {
+	perm, found := PermissionNone, false
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(t.token, []byte(token)) == 1 && !found {
+			perm, found = t.perm, true
+		}
+	}
+	return perm, found
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestAuthCheck(t *testing.T) {
+	auth := NewAuth([]string{"driver"}, []string{"passenger"})
+
+	tt := []struct {
+		name     string
+		inAuth   *Auth
+		inToken  string
+		inNeed   Permission
+		wantCode int
+	}{
+		{name: "NilAuth", inAuth: nil, inToken: "", inNeed: PermissionControl, wantCode: 0},
+		{name: "NoTokens", inAuth: NewAuth(nil, nil), inToken: "", inNeed: PermissionControl, wantCode: 0},
+		{name: "NoneRequired", inAuth: auth, inToken: "", inNeed: PermissionNone, wantCode: 0},
+		{name: "ControlControl", inAuth: auth, inToken: "driver", inNeed: PermissionControl, wantCode: 0},
+		{name: "ControlView", inAuth: auth, inToken: "driver", inNeed: PermissionView, wantCode: 0},
+		{name: "ViewView", inAuth: auth, inToken: "passenger", inNeed: PermissionView, wantCode: 0},
+		{name: "ViewControl", inAuth: auth, inToken: "passenger", inNeed: PermissionControl, wantCode: http.StatusForbidden},
+		{name: "Missing", inAuth: auth, inToken: "", inNeed: PermissionView, wantCode: http.StatusUnauthorized},
+		{name: "Unknown", inAuth: auth, inToken: "driverx", inNeed: PermissionView, wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			resp := tc.inAuth.Check(tc.inToken, tc.inNeed)
+			gotCode := 0
+			if resp != nil {
+				gotCode = resp.Code
+			}
+			if gotCode != tc.wantCode {
+				t.Errorf("code: expected %d, got %d", tc.wantCode, gotCode)
+			}
+		})
+	}
+}
+
+func TestAuthGrant(t *testing.T) {
+	auth := NewAuth([]string{"driver"}, []string{"passenger"})
+
+	tt := []struct {
+		name     string
+		inAuth   *Auth
+		inToken  string
+		wantPerm Permission
+	}{
+		{name: "NilAuth", inAuth: nil, inToken: "", wantPerm: PermissionControl},
+		{name: "NoTokens", inAuth: NewAuth(nil, nil), inToken: "", wantPerm: PermissionControl},
+		{name: "Control", inAuth: auth, inToken: "driver", wantPerm: PermissionControl},
+		{name: "View", inAuth: auth, inToken: "passenger", wantPerm: PermissionView},
+		{name: "Unknown", inAuth: auth, inToken: "driverx", wantPerm: PermissionNone},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			gotPerm := tc.inAuth.Grant(tc.inToken)
+			if gotPerm != tc.wantPerm {
+				t.Errorf("perm: expected %d, got %d", tc.wantPerm, gotPerm)
+			}
+		})
+	}
+}
+
+func TestServiceHandlerAuth(t *testing.T) {
+	auth := NewAuth([]string{"driver"}, []string{"passenger"})
+
+	tt := []struct {
+		name      string
+		inTarget  string
+		inPerm    Permission
+		wantCode  int
+		wantQuery url.Values
+	}{
+		{
+			name:      "Control",
+			inTarget:  "/keyboard?key=a&token=driver",
+			inPerm:    PermissionControl,
+			wantCode:  http.StatusOK,
+			wantQuery: url.Values{"key": {"a"}},
+		},
+		{
+			name:      "ViewOnly",
+			inTarget:  "/keyboard?key=a&token=passenger",
+			inPerm:    PermissionControl,
+			wantCode:  http.StatusForbidden,
+			wantQuery: nil,
+		},
+		{
+			name:      "View",
+			inTarget:  "/screen?token=passenger",
+			inPerm:    PermissionView,
+			wantCode:  http.StatusOK,
+			wantQuery: url.Values{},
+		},
+		{
+			name:      "NoToken",
+			inTarget:  "/screen",
+			inPerm:    PermissionView,
+			wantCode:  http.StatusUnauthorized,
+			wantQuery: nil,
+		},
+		{
+			name:      "Health",
+			inTarget:  "/healthz",
+			inPerm:    PermissionNone,
+			wantCode:  http.StatusOK,
+			wantQuery: url.Values{},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &MockService{Resp: &ServiceResponse{Code: http.StatusOK}}
+			h := &ServiceHandler{
+				Service:    srv,
+				Auth:       auth,
+				Permission: tc.inPerm,
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", tc.inTarget, nil))
+
+			if w.Code != tc.wantCode {
+				t.Errorf("code: expected %d, got %d", tc.wantCode, w.Code)
+			}
+			if !reflect.DeepEqual(srv.Query, tc.wantQuery) {
+				t.Errorf("query: expected %#v, got %#v", tc.wantQuery, srv.Query)
+			}
+		})
+	}
+}
+
+func TestServiceHandlerViewService(t *testing.T) {
+	tt := []struct {
+		name     string
+		inAuth   *Auth
+		inTarget string
+		wantView bool
+	}{
+		{
+			name:     "Control",
+			inAuth:   NewAuth([]string{"driver"}, []string{"passenger"}),
+			inTarget: "/screen?token=driver",
+			wantView: false,
+		},
+		{
+			name:     "View",
+			inAuth:   NewAuth([]string{"driver"}, []string{"passenger"}),
+			inTarget: "/screen?token=passenger",
+			wantView: true,
+		},
+		{
+			name:     "NoAuth",
+			inAuth:   nil,
+			inTarget: "/screen",
+			wantView: false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &MockService{Resp: &ServiceResponse{Code: http.StatusOK}}
+			view := &MockService{Resp: &ServiceResponse{Code: http.StatusConflict}}
+			h := &ServiceHandler{
+				Service:     srv,
+				Auth:        tc.inAuth,
+				Permission:  PermissionView,
+				ViewService: view,
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", tc.inTarget, nil))
+
+			gotView := view.Query != nil
+			if gotView != tc.wantView {
+				t.Errorf("view: expected %t, got %t", tc.wantView, gotView)
+			}
+			if gotView == (srv.Query != nil) {
+				t.Errorf("service: expected exactly one service to be called")
+			}
+		})
+	}
+}
@@ -9,16 +9,20 @@ import (
 
 func EncodeScreenShot(ss vterm.ScreenShot) []byte {
 	buf := new(bytes.Buffer)
-	encodeScreenShot(buf, ss)
+	encodeScreenShot(buf, ss, false)
 	return buf.Bytes()
 }
 
-func encodeScreenShot(buf *bytes.Buffer, ss vterm.ScreenShot) {
-	encodeBool(buf, ss.CursorVisible)
-	encodeBool(buf, ss.CursorBlink)
-	_ = buf.WriteByte(byte(ss.CursorShape))
-	encodeInt(buf, ss.CursorPos.Row)
-	encodeInt(buf, ss.CursorPos.Col)
+// EncodeScreenShotFlat is like EncodeScreenShot but omits the cell
+// attributes. ss is expected to be flattened beforehand.
+func EncodeScreenShotFlat(ss vterm.ScreenShot) []byte {
+	buf := new(bytes.Buffer)
+	encodeScreenShot(buf, ss, true)
+	return buf.Bytes()
+}
+
+func encodeScreenShot(buf *bytes.Buffer, ss vterm.ScreenShot, flat bool) {
+	encodeCursor(buf, ss)
 
 	rows, cols := ss.Size()
 	encodeInt(buf, rows)
@@ -28,28 +32,107 @@ func encodeScreenShot(buf *bytes.Buffer, ss vterm.ScreenShot) {
 			pos := vterm.Pos{Row: row, Col: col}
 			cell := ss.At(pos)
 
-			encodeBool(buf, cell.Attrs.Bold)
-			_ = buf.WriteByte(byte(cell.Attrs.Underline))
-			encodeBool(buf, cell.Attrs.Italic)
-			encodeBool(buf, cell.Attrs.Blink)
-			encodeBool(buf, cell.Attrs.Reverse)
-			encodeBool(buf, cell.Attrs.Conceal)
-			encodeBool(buf, cell.Attrs.Strike)
-			_ = buf.WriteByte(byte(cell.Attrs.Font))
-			encodeBool(buf, cell.Attrs.DWL)
-			_ = buf.WriteByte(byte(cell.Attrs.DHL))
-			encodeBool(buf, cell.Attrs.Small)
-			_ = buf.WriteByte(byte(cell.Attrs.Baseline))
-
+			if !flat {
+				encodeCellAttrs(buf, cell.Attrs)
+			}
 			encodeColor(buf, cell.FG)
 			encodeColor(buf, cell.BG)
+			_ = buf.WriteByte(byte(cell.Width))
+			encodeString(buf, string(cell.Runes))
+		}
+	}
+}
+
+const (
+	indexedColorDefaultFG byte = 0x10
+	indexedColorDefaultBG byte = 0x11
+	indexedColorRGB       byte = 0xFF
+)
+
+// EncodeScreenShotIndexed encodes ss with the palette sent once in the
+// header and each cell color as a single byte: 0x00-0x0F for the ANSI
+// colors, 0x10 and 0x11 for the default fg and bg, or 0xFF followed by
+// 3 RGB bytes. Indexed colors beyond 0x0F must be resolved to RGB by
+// the caller.
+func EncodeScreenShotIndexed(ss vterm.ScreenShot, pal Theme) []byte {
+	buf := new(bytes.Buffer)
+	encodeScreenShotIndexed(buf, ss, pal, false)
+	return buf.Bytes()
+}
+
+// EncodeScreenShotIndexedFlat is like EncodeScreenShotIndexed but omits
+// the cell attributes. ss is expected to be flattened beforehand.
+func EncodeScreenShotIndexedFlat(ss vterm.ScreenShot, pal Theme) []byte {
+	buf := new(bytes.Buffer)
+	encodeScreenShotIndexed(buf, ss, pal, true)
+	return buf.Bytes()
+}
+
+func encodeScreenShotIndexed(buf *bytes.Buffer, ss vterm.ScreenShot, pal Theme, flat bool) {
+	encodeCursor(buf, ss)
+
+	for _, col := range pal.Palette {
+		encodeColor(buf, col)
+	}
+	encodeColor(buf, pal.FG)
+	encodeColor(buf, pal.BG)
+
+	rows, cols := ss.Size()
+	encodeInt(buf, rows)
+	encodeInt(buf, cols)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			pos := vterm.Pos{Row: row, Col: col}
+			cell := ss.At(pos)
 
+			if !flat {
+				encodeCellAttrs(buf, cell.Attrs)
+			}
+			encodeIndexedColor(buf, cell.FG)
+			encodeIndexedColor(buf, cell.BG)
 			_ = buf.WriteByte(byte(cell.Width))
 			encodeString(buf, string(cell.Runes))
 		}
 	}
 }
 
+func encodeCursor(buf *bytes.Buffer, ss vterm.ScreenShot) {
+	encodeBool(buf, ss.CursorVisible)
+	encodeBool(buf, ss.CursorBlink)
+	_ = buf.WriteByte(byte(ss.CursorShape))
+	encodeInt(buf, ss.CursorPos.Row)
+	encodeInt(buf, ss.CursorPos.Col)
+}
+
+func encodeCellAttrs(buf *bytes.Buffer, attrs vterm.CellAttrs) {
+	encodeBool(buf, attrs.Bold)
+	_ = buf.WriteByte(byte(attrs.Underline))
+	encodeBool(buf, attrs.Italic)
+	encodeBool(buf, attrs.Blink)
+	encodeBool(buf, attrs.Reverse)
+	encodeBool(buf, attrs.Conceal)
+	encodeBool(buf, attrs.Strike)
+	_ = buf.WriteByte(byte(attrs.Font))
+	encodeBool(buf, attrs.DWL)
+	_ = buf.WriteByte(byte(attrs.DHL))
+	encodeBool(buf, attrs.Small)
+	_ = buf.WriteByte(byte(attrs.Baseline))
+}
+
+func encodeIndexedColor(buf *bytes.Buffer, col vterm.Color) {
+	switch {
+	case col.IsDefaultFG():
+		_ = buf.WriteByte(indexedColorDefaultFG)
+	case col.IsDefaultBG():
+		_ = buf.WriteByte(indexedColorDefaultBG)
+	case col.IsIndexed() && col.Idx < 16:
+		_ = buf.WriteByte(col.Idx)
+	default:
+		_ = buf.WriteByte(indexedColorRGB)
+		encodeColor(buf, col)
+	}
+}
+
 func encodeColor(buf *bytes.Buffer, col vterm.Color) {
 	var b [3]byte
 	if col.IsRGB() {
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gcrtnst/sw-term-server/internal/vterm"
+)
+
+var cursorShapeNames = map[vterm.CursorShape]string{
+	vterm.CursorShapeBlock:     "block",
+	vterm.CursorShapeUnderline: "underline",
+	vterm.CursorShapeBarLeft:   "bar_left",
+}
+
+type statusJSON struct {
+	Running  bool         `json:"running"`
+	Size     *sizeJSON    `json:"size,omitempty"`
+	Cursor   *cursorJSON  `json:"cursor,omitempty"`
+	Title    *string      `json:"title,omitempty"`
+	Process  *processJSON `json:"process,omitempty"`
+	Uptime   *float64     `json:"uptime,omitempty"`
+	ExitCode *int         `json:"exit_code,omitempty"`
+}
+
+type sizeJSON struct {
+	Rows int `json:"rows"`
+	Cols int `json:"cols"`
+}
+
+type cursorJSON struct {
+	Row     int    `json:"row"`
+	Col     int    `json:"col"`
+	Visible bool   `json:"visible"`
+	Blink   bool   `json:"blink"`
+	Shape   string `json:"shape"`
+}
+
+type processJSON struct {
+	PID     int    `json:"pid"`
+	Command string `json:"command"`
+	Idle    bool   `json:"idle"`
+}
+
+type screenJSON struct {
+	Size   sizeJSON     `json:"size"`
+	Cursor cursorJSON   `json:"cursor"`
+	Cells  [][]cellJSON `json:"cells"`
+}
+
+type cellJSON struct {
+	Text  string    `json:"text"`
+	Width int       `json:"width"`
+	FG    string    `json:"fg"`
+	BG    string    `json:"bg"`
+	Attrs attrsJSON `json:"attrs"`
+}
+
+type attrsJSON struct {
+	Bold      bool `json:"bold,omitempty"`
+	Underline int  `json:"underline,omitempty"`
+	Italic    bool `json:"italic,omitempty"`
+	Blink     bool `json:"blink,omitempty"`
+	Reverse   bool `json:"reverse,omitempty"`
+	Conceal   bool `json:"conceal,omitempty"`
+	Strike    bool `json:"strike,omitempty"`
+	Font      int  `json:"font,omitempty"`
+	DWL       bool `json:"dwl,omitempty"`
+	DHL       int  `json:"dhl,omitempty"`
+	Small     bool `json:"small,omitempty"`
+	Baseline  int  `json:"baseline,omitempty"`
+}
+
+func EncodeStatusJSON(st TermStatus, now time.Time) []byte {
+	v := statusJSON{Running: st.Running}
+	if st.Running || st.Exited {
+		v.Size = &sizeJSON{Rows: st.Row, Cols: st.Col}
+		v.Cursor = &cursorJSON{
+			Row:     st.CursorPos.Row,
+			Col:     st.CursorPos.Col,
+			Visible: st.CursorVisible,
+			Blink:   st.CursorBlink,
+			Shape:   cursorShapeNames[st.CursorShape],
+		}
+		v.Title = &st.Title
+	}
+	if st.Running {
+		uptime := now.Sub(st.Started).Seconds()
+		v.Uptime = &uptime
+	}
+	if st.Exited {
+		v.ExitCode = &st.ExitCode
+	}
+	if st.Foreground.PID > 0 {
+		v.Process = &processJSON{
+			PID:     st.Foreground.PID,
+			Command: st.Foreground.Name,
+			Idle:    st.Idle,
+		}
+	}
+
+	b, _ := json.Marshal(v)
+	return b
+}
+
+func EncodeScreenShotJSON(ss vterm.ScreenShot) []byte {
+	rows, cols := ss.Size()
+	v := screenJSON{
+		Size: sizeJSON{Rows: rows, Cols: cols},
+		Cursor: cursorJSON{
+			Row:     ss.CursorPos.Row,
+			Col:     ss.CursorPos.Col,
+			Visible: ss.CursorVisible,
+			Blink:   ss.CursorBlink,
+			Shape:   cursorShapeNames[ss.CursorShape],
+		},
+		Cells: make([][]cellJSON, rows),
+	}
+	for row := 0; row < rows; row++ {
+		v.Cells[row] = make([]cellJSON, cols)
+		for col := 0; col < cols; col++ {
+			pos := vterm.Pos{Row: row, Col: col}
+			cell := ss.At(pos)
+
+			v.Cells[row][col] = cellJSON{
+				Text:  string(cell.Runes),
+				Width: cell.Width,
+				FG:    hexColor(cell.FG),
+				BG:    hexColor(cell.BG),
+				Attrs: attrsJSON{
+					Bold:      cell.Attrs.Bold,
+					Underline: int(cell.Attrs.Underline),
+					Italic:    cell.Attrs.Italic,
+					Blink:     cell.Attrs.Blink,
+					Reverse:   cell.Attrs.Reverse,
+					Conceal:   cell.Attrs.Conceal,
+					Strike:    cell.Attrs.Strike,
+					Font:      cell.Attrs.Font,
+					DWL:       cell.Attrs.DWL,
+					DHL:       int(cell.Attrs.DHL),
+					Small:     cell.Attrs.Small,
+					Baseline:  int(cell.Attrs.Baseline),
+				},
+			}
+		}
+	}
+
+	b, _ := json.Marshal(v)
+	return b
+}
+
+func hexColor(col vterm.Color) string {
+	if !col.IsRGB() {
+		return "#000000"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", col.Red, col.Green, col.Blue)
+}
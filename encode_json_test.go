@@ -0,0 +1,154 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gcrtnst/sw-term-server/internal/vterm"
+	"github.com/gcrtnst/sw-term-server/internal/xpty"
+)
+
+func TestEncodeStatusJSON(t *testing.T) {
+	now := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name  string
+		inST  TermStatus
+		inNow time.Time
+		want  string
+	}{
+		{
+			name:  "NotRunning",
+			inST:  TermStatus{},
+			inNow: now,
+			want:  `{"running":false}`,
+		},
+		{
+			name: "Running",
+			inST: TermStatus{
+				Running:       true,
+				Row:           27,
+				Col:           58,
+				CursorPos:     vterm.Pos{Row: 1, Col: 2},
+				CursorVisible: true,
+				CursorBlink:   false,
+				CursorShape:   vterm.CursorShapeUnderline,
+				Title:         "title",
+				Started:       now.Add(-90 * time.Second),
+			},
+			inNow: now,
+			want:  `{"running":true,"size":{"rows":27,"cols":58},"cursor":{"row":1,"col":2,"visible":true,"blink":false,"shape":"underline"},"title":"title","uptime":90}`,
+		},
+		{
+			name: "Process",
+			inST: TermStatus{
+				Running:       true,
+				Row:           27,
+				Col:           58,
+				Foreground:    xpty.Process{PID: 1234, Name: "vim"},
+				Idle:          false,
+				CursorPos:     vterm.Pos{Row: 0, Col: 0},
+				CursorVisible: true,
+				CursorBlink:   true,
+				CursorShape:   vterm.CursorShapeBlock,
+				Title:         "",
+				Started:       now.Add(-1500 * time.Millisecond),
+			},
+			inNow: now,
+			want:  `{"running":true,"size":{"rows":27,"cols":58},"cursor":{"row":0,"col":0,"visible":true,"blink":true,"shape":"block"},"title":"","process":{"pid":1234,"command":"vim","idle":false},"uptime":1.5}`,
+		},
+		{
+			name: "Exited",
+			inST: TermStatus{
+				Exited:        true,
+				ExitCode:      2,
+				Row:           27,
+				Col:           58,
+				CursorPos:     vterm.Pos{Row: 3, Col: 0},
+				CursorVisible: true,
+				CursorBlink:   true,
+				CursorShape:   vterm.CursorShapeBlock,
+				Title:         "",
+				Started:       now.Add(-90 * time.Second),
+			},
+			inNow: now,
+			want:  `{"running":false,"size":{"rows":27,"cols":58},"cursor":{"row":3,"col":0,"visible":true,"blink":true,"shape":"block"},"title":"","exit_code":2}`,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := string(EncodeStatusJSON(tc.inST, tc.inNow))
+			if got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEncodeScreenShotJSON(t *testing.T) {
+	tt := []struct {
+		name string
+		in   vterm.ScreenShot
+		want string
+	}{
+		{
+			name: "Zero",
+			in:   vterm.ScreenShot{},
+			want: `{"size":{"rows":0,"cols":0},"cursor":{"row":0,"col":0,"visible":false,"blink":false,"shape":""},"cells":[]}`,
+		},
+		{
+			name: "Cells",
+			in: vterm.ScreenShot{
+				Stride: 2,
+				Cell: []vterm.Cell{
+					{
+						Runes: []rune{'A'},
+						Width: 1,
+						FG:    vterm.NewColorRGB(0xC4, 0xC4, 0xC4),
+						BG:    vterm.NewColorRGB(0x00, 0x00, 0x00),
+					},
+					{
+						Runes: []rune{'e', '́'},
+						Width: 1,
+						Attrs: vterm.CellAttrs{
+							Bold:      true,
+							Underline: vterm.UnderlineDouble,
+							Italic:    true,
+							Blink:     true,
+							Reverse:   true,
+							Conceal:   true,
+							Strike:    true,
+							Font:      3,
+							DWL:       true,
+							DHL:       vterm.DHLBottom,
+							Small:     true,
+							Baseline:  vterm.BaselineLower,
+						},
+						FG: vterm.NewColorRGB(0x12, 0x34, 0x56),
+						BG: vterm.NewColorIndexed(1),
+					},
+				},
+				CursorPos:     vterm.Pos{Row: 0, Col: 1},
+				CursorVisible: true,
+				CursorBlink:   true,
+				CursorShape:   vterm.CursorShapeBarLeft,
+			},
+			want: `{"size":{"rows":1,"cols":2},"cursor":{"row":0,"col":1,"visible":true,"blink":true,"shape":"bar_left"},"cells":[[` +
+				`{"text":"A","width":1,"fg":"#c4c4c4","bg":"#000000","attrs":{}},` +
+				`{"text":"é","width":1,"fg":"#123456","bg":"#000000","attrs":{"bold":true,"underline":2,"italic":true,"blink":true,"reverse":true,"conceal":true,"strike":true,"font":3,"dwl":true,"dhl":2,"small":true,"baseline":2}}` +
+				`]]}`,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := string(EncodeScreenShotJSON(tc.in))
+			if got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
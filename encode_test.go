@@ -785,3 +785,227 @@ func TestEscapeZero(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeScreenShotIndexed(t *testing.T) {
+	var pal Theme
+	for idx := range pal.Palette {
+		pal.Palette[idx] = vterm.NewColorRGB(byte(idx), byte(idx), byte(idx))
+	}
+	pal.FG = vterm.NewColorRGB(0xC4, 0xC4, 0xC4)
+	pal.FG.Type |= vterm.ColorDefaultFG
+	pal.BG = vterm.NewColorRGB(0x00, 0x00, 0x00)
+	pal.BG.Type |= vterm.ColorDefaultBG
+
+	tt := []struct {
+		name  string
+		inSS  vterm.ScreenShot
+		inPal Theme
+		want  []byte
+	}{
+		{
+			name:  "Zero",
+			inSS:  vterm.ScreenShot{},
+			inPal: Theme{},
+			want: []byte{
+				0x00,                                           // CursorVisible
+				0x00,                                           // CursorBlink
+				0x00,                                           // CursorShape
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // CursorPos.Row
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // CursorPos.Col
+				0x00, 0x00, 0x00, // Palette[0]
+				0x00, 0x00, 0x00, // Palette[1]
+				0x00, 0x00, 0x00, // Palette[2]
+				0x00, 0x00, 0x00, // Palette[3]
+				0x00, 0x00, 0x00, // Palette[4]
+				0x00, 0x00, 0x00, // Palette[5]
+				0x00, 0x00, 0x00, // Palette[6]
+				0x00, 0x00, 0x00, // Palette[7]
+				0x00, 0x00, 0x00, // Palette[8]
+				0x00, 0x00, 0x00, // Palette[9]
+				0x00, 0x00, 0x00, // Palette[10]
+				0x00, 0x00, 0x00, // Palette[11]
+				0x00, 0x00, 0x00, // Palette[12]
+				0x00, 0x00, 0x00, // Palette[13]
+				0x00, 0x00, 0x00, // Palette[14]
+				0x00, 0x00, 0x00, // Palette[15]
+				0x00, 0x00, 0x00, // FG
+				0x00, 0x00, 0x00, // BG
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Rows
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cols
+			},
+		},
+		{
+			name: "Colors",
+			inSS: vterm.ScreenShot{
+				Stride: 4,
+				Cell: []vterm.Cell{
+					{
+						Runes: []rune{'A'},
+						Width: 1,
+						FG:    pal.FG,
+						BG:    pal.BG,
+					},
+					{
+						Runes: []rune{'B'},
+						Width: 1,
+						FG:    vterm.NewColorIndexed(1),
+						BG:    vterm.NewColorIndexed(9),
+					},
+					{
+						Runes: []rune{'C'},
+						Width: 1,
+						FG:    vterm.NewColorRGB(0x12, 0x34, 0x56),
+						BG:    pal.BG,
+					},
+					{
+						Runes: []rune{'D'},
+						Width: 1,
+						FG:    pal.FG,
+						BG:    vterm.NewColorRGB(0x00, 0x00, 0x00),
+					},
+				},
+			},
+			inPal: pal,
+			want: []byte{
+				0x00,                                           // CursorVisible
+				0x00,                                           // CursorBlink
+				0x00,                                           // CursorShape
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // CursorPos.Row
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // CursorPos.Col
+				0x00, 0x00, 0x00, // Palette[0]
+				0x01, 0x01, 0x01, // Palette[1]
+				0x02, 0x02, 0x02, // Palette[2]
+				0x03, 0x03, 0x03, // Palette[3]
+				0x04, 0x04, 0x04, // Palette[4]
+				0x05, 0x05, 0x05, // Palette[5]
+				0x06, 0x06, 0x06, // Palette[6]
+				0x07, 0x07, 0x07, // Palette[7]
+				0x08, 0x08, 0x08, // Palette[8]
+				0x09, 0x09, 0x09, // Palette[9]
+				0x0A, 0x0A, 0x0A, // Palette[10]
+				0x0B, 0x0B, 0x0B, // Palette[11]
+				0x0C, 0x0C, 0x0C, // Palette[12]
+				0x0D, 0x0D, 0x0D, // Palette[13]
+				0x0E, 0x0E, 0x0E, // Palette[14]
+				0x0F, 0x0F, 0x0F, // Palette[15]
+				0xC4, 0xC4, 0xC4, // FG
+				0x00, 0x00, 0x00, // BG
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Rows
+				0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cols
+
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cell[i].Attrs.Bold-Strike
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cell[i].Attrs.Font-Baseline
+				0x10,                                           // Cell[i].FG
+				0x11,                                           // Cell[i].BG
+				0x01,                                           // Cell[i].Width
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // len(Cell[i].Rune)
+				'A', // string(Cell[i].Rune)
+
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cell[i].Attrs.Bold-Strike
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cell[i].Attrs.Font-Baseline
+				0x01,                                           // Cell[i].FG
+				0x09,                                           // Cell[i].BG
+				0x01,                                           // Cell[i].Width
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // len(Cell[i].Rune)
+				'B', // string(Cell[i].Rune)
+
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cell[i].Attrs.Bold-Strike
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cell[i].Attrs.Font-Baseline
+				0xFF, 0x12, 0x34, 0x56, // Cell[i].FG
+				0x11,                                           // Cell[i].BG
+				0x01,                                           // Cell[i].Width
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // len(Cell[i].Rune)
+				'C', // string(Cell[i].Rune)
+
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cell[i].Attrs.Bold-Strike
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cell[i].Attrs.Font-Baseline
+				0x10,                   // Cell[i].FG
+				0xFF, 0x00, 0x00, 0x00, // Cell[i].BG
+				0x01,                                           // Cell[i].Width
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // len(Cell[i].Rune)
+				'D', // string(Cell[i].Rune)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := EncodeScreenShotIndexed(tc.inSS, tc.inPal)
+			if !bytes.Equal(got, tc.want) {
+				t.Errorf("expected %X, got %X", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEncodeScreenShotFlat(t *testing.T) {
+	in := vterm.ScreenShot{
+		Stride: 1,
+		Cell: []vterm.Cell{
+			{
+				Runes: []rune{'A'},
+				Width: 1,
+				FG:    vterm.NewColorIndexed(9),
+				BG:    vterm.NewColorRGB(0x12, 0x34, 0x56),
+			},
+		},
+	}
+	var pal Theme
+
+	tt := []struct {
+		name string
+		fn   func() []byte
+		want []byte
+	}{
+		{
+			name: "RGB",
+			fn:   func() []byte { return EncodeScreenShotFlat(ResolveColors(in, pal)) },
+			want: []byte{
+				0x00,                                           // CursorVisible
+				0x00,                                           // CursorBlink
+				0x00,                                           // CursorShape
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // CursorPos.Row
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // CursorPos.Col
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Rows
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cols
+
+				0x00, 0x00, 0x00, // Cell[i].FG
+				0x12, 0x34, 0x56, // Cell[i].BG
+				0x01,                                           // Cell[i].Width
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // len(Cell[i].Rune)
+				'A', // string(Cell[i].Rune)
+			},
+		},
+		{
+			name: "Indexed",
+			fn:   func() []byte { return EncodeScreenShotIndexedFlat(in, pal) },
+			want: append(append([]byte{
+				0x00,                                           // CursorVisible
+				0x00,                                           // CursorBlink
+				0x00,                                           // CursorShape
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // CursorPos.Row
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // CursorPos.Col
+			}, make([]byte, 18*3)...), []byte{ // Palette, FG, BG
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Rows
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Cols
+
+				0x09,                   // Cell[i].FG
+				0xFF, 0x12, 0x34, 0x56, // Cell[i].BG
+				0x01,                                           // Cell[i].Width
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // len(Cell[i].Rune)
+				'A', // string(Cell[i].Rune)
+			}...),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.fn()
+			if !bytes.Equal(got, tc.want) {
+				t.Errorf("expected %X, got %X", tc.want, got)
+			}
+		})
+	}
+}
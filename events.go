@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sync"
+)
+
+const DefaultEventQueueSize = 64
+
+const (
+	EventBell      = "bell"
+	EventTitle     = "title"
+	EventExit      = "exit"
+	EventClipboard = "clipboard"
+	EventResize    = "resize"
+)
+
+type Event struct {
+	Seq  uint64
+	Type string
+	Data string
+}
+
+// EventQueue keeps the most recent events of the terminals. Sequence numbers
+// start at 1 and never repeat, so a client can tell from a gap in the
+// numbers that events were dropped.
+type EventQueue struct {
+	mu  sync.Mutex
+	buf []Event
+	cap int
+	seq uint64
+}
+
+func NewEventQueue(capacity int) *EventQueue {
+	return &EventQueue{
+		buf: make([]Event, 0, capacity),
+		cap: capacity,
+	}
+}
+
+func (q *EventQueue) Push(typ, data string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	ev := Event{Seq: q.seq, Type: typ, Data: data}
+	if len(q.buf) >= q.cap {
+		copy(q.buf, q.buf[1:])
+		q.buf = q.buf[:len(q.buf)-1]
+	}
+	q.buf = append(q.buf, ev)
+}
+
+// Since returns the queued events with a sequence number greater than seq,
+// along with the sequence number of the latest event.
+func (q *EventQueue) Since(seq uint64) ([]Event, uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	events := []Event{}
+	for _, ev := range q.buf {
+		if ev.Seq > seq {
+			events = append(events, ev)
+		}
+	}
+	return events, q.seq
+}
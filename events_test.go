@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventQueueSince(t *testing.T) {
+	tt := []struct {
+		name       string
+		inCap      int
+		inPush     []string
+		inSince    uint64
+		wantEvents []Event
+		wantLast   uint64
+	}{
+		{
+			name:       "Empty",
+			inCap:      4,
+			inPush:     nil,
+			inSince:    0,
+			wantEvents: []Event{},
+			wantLast:   0,
+		},
+		{
+			name:    "All",
+			inCap:   4,
+			inPush:  []string{EventBell, EventTitle},
+			inSince: 0,
+			wantEvents: []Event{
+				{Seq: 1, Type: EventBell},
+				{Seq: 2, Type: EventTitle},
+			},
+			wantLast: 2,
+		},
+		{
+			name:    "Since",
+			inCap:   4,
+			inPush:  []string{EventBell, EventTitle, EventResize},
+			inSince: 2,
+			wantEvents: []Event{
+				{Seq: 3, Type: EventResize},
+			},
+			wantLast: 3,
+		},
+		{
+			name:       "UpToDate",
+			inCap:      4,
+			inPush:     []string{EventBell, EventTitle},
+			inSince:    2,
+			wantEvents: []Event{},
+			wantLast:   2,
+		},
+		{
+			name:    "Overflow",
+			inCap:   2,
+			inPush:  []string{EventBell, EventTitle, EventClipboard, EventExit},
+			inSince: 0,
+			wantEvents: []Event{
+				{Seq: 3, Type: EventClipboard},
+				{Seq: 4, Type: EventExit},
+			},
+			wantLast: 4,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			q := NewEventQueue(tc.inCap)
+			for _, typ := range tc.inPush {
+				q.Push(typ, "")
+			}
+
+			gotEvents, gotLast := q.Since(tc.inSince)
+			if !reflect.DeepEqual(gotEvents, tc.wantEvents) {
+				t.Errorf("events: expected %#v, got %#v", tc.wantEvents, gotEvents)
+			}
+			if gotLast != tc.wantLast {
+				t.Errorf("last: expected %d, got %d", tc.wantLast, gotLast)
+			}
+		})
+	}
+}
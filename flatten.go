@@ -0,0 +1,49 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gcrtnst/sw-term-server/internal/vterm"
+)
+
+const BlinkInterval = 500 * time.Millisecond
+
+// Flatten bakes the visual attributes of each cell into its colors and
+// clears the attributes. Reverse swaps FG and BG, conceal and the off
+// phase of blink hide the text by setting FG to BG, and bold brightens
+// the 8 basic ANSI colors. libvterm does not track dim (SGR 2), so there
+// is nothing to apply for it.
+//
+// ss must come from an unconverted capture so that indexed colors can be
+// brightened.
+func Flatten(ss vterm.ScreenShot, blinkOn bool) vterm.ScreenShot {
+	cell := make([]vterm.Cell, len(ss.Cell))
+	copy(cell, ss.Cell)
+	for idx := range cell {
+		cell[idx] = flattenCell(cell[idx], blinkOn)
+	}
+
+	ss.Cell = cell
+	return ss
+}
+
+func BlinkPhase(now time.Time) bool {
+	return now.UnixNano()/int64(BlinkInterval)%2 == 0
+}
+
+func flattenCell(cell vterm.Cell, blinkOn bool) vterm.Cell {
+	fg, bg := cell.FG, cell.BG
+	if cell.Attrs.Bold && fg.IsIndexed() && fg.Idx < 8 {
+		fg = vterm.NewColorIndexed(fg.Idx + 8)
+	}
+	if cell.Attrs.Reverse {
+		fg, bg = bg, fg
+	}
+	if cell.Attrs.Conceal || (cell.Attrs.Blink && !blinkOn) {
+		fg = bg
+	}
+
+	cell.FG, cell.BG = fg, bg
+	cell.Attrs = vterm.CellAttrs{}
+	return cell
+}
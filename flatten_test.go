@@ -0,0 +1,171 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gcrtnst/sw-term-server/internal/vterm"
+)
+
+func TestFlatten(t *testing.T) {
+	defFG := vterm.NewColorRGB(0xC4, 0xC4, 0xC4)
+	defFG.Type |= vterm.ColorDefaultFG
+	defBG := vterm.NewColorRGB(0x00, 0x00, 0x00)
+	defBG.Type |= vterm.ColorDefaultBG
+	red := vterm.NewColorIndexed(1)
+	brightRed := vterm.NewColorIndexed(9)
+	blue := vterm.NewColorIndexed(4)
+	rgb := vterm.NewColorRGB(0x12, 0x34, 0x56)
+
+	tt := []struct {
+		name      string
+		inCell    vterm.Cell
+		inBlinkOn bool
+		wantFG    vterm.Color
+		wantBG    vterm.Color
+	}{
+		{
+			name:      "Plain",
+			inCell:    vterm.Cell{FG: defFG, BG: defBG},
+			inBlinkOn: true,
+			wantFG:    defFG,
+			wantBG:    defBG,
+		},
+		{
+			name:      "Bold",
+			inCell:    vterm.Cell{Attrs: vterm.CellAttrs{Bold: true}, FG: red, BG: defBG},
+			inBlinkOn: true,
+			wantFG:    brightRed,
+			wantBG:    defBG,
+		},
+		{
+			name:      "BoldBright",
+			inCell:    vterm.Cell{Attrs: vterm.CellAttrs{Bold: true}, FG: brightRed, BG: defBG},
+			inBlinkOn: true,
+			wantFG:    brightRed,
+			wantBG:    defBG,
+		},
+		{
+			name:      "BoldRGB",
+			inCell:    vterm.Cell{Attrs: vterm.CellAttrs{Bold: true}, FG: rgb, BG: defBG},
+			inBlinkOn: true,
+			wantFG:    rgb,
+			wantBG:    defBG,
+		},
+		{
+			name:      "Reverse",
+			inCell:    vterm.Cell{Attrs: vterm.CellAttrs{Reverse: true}, FG: red, BG: blue},
+			inBlinkOn: true,
+			wantFG:    blue,
+			wantBG:    red,
+		},
+		{
+			name:      "BoldReverse",
+			inCell:    vterm.Cell{Attrs: vterm.CellAttrs{Bold: true, Reverse: true}, FG: red, BG: blue},
+			inBlinkOn: true,
+			wantFG:    blue,
+			wantBG:    brightRed,
+		},
+		{
+			name:      "Conceal",
+			inCell:    vterm.Cell{Attrs: vterm.CellAttrs{Conceal: true}, FG: red, BG: blue},
+			inBlinkOn: true,
+			wantFG:    blue,
+			wantBG:    blue,
+		},
+		{
+			name:      "ReverseConceal",
+			inCell:    vterm.Cell{Attrs: vterm.CellAttrs{Reverse: true, Conceal: true}, FG: red, BG: blue},
+			inBlinkOn: true,
+			wantFG:    red,
+			wantBG:    red,
+		},
+		{
+			name:      "BlinkOn",
+			inCell:    vterm.Cell{Attrs: vterm.CellAttrs{Blink: true}, FG: red, BG: blue},
+			inBlinkOn: true,
+			wantFG:    red,
+			wantBG:    blue,
+		},
+		{
+			name:      "BlinkOff",
+			inCell:    vterm.Cell{Attrs: vterm.CellAttrs{Blink: true}, FG: red, BG: blue},
+			inBlinkOn: false,
+			wantFG:    blue,
+			wantBG:    blue,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tc.inCell.Runes = []rune{'A'}
+			tc.inCell.Width = 1
+			in := vterm.ScreenShot{
+				Stride: 1,
+				Cell:   []vterm.Cell{tc.inCell},
+			}
+			want := vterm.ScreenShot{
+				Stride: 1,
+				Cell: []vterm.Cell{
+					{
+						Runes: []rune{'A'},
+						Width: 1,
+						FG:    tc.wantFG,
+						BG:    tc.wantBG,
+					},
+				},
+			}
+
+			got := Flatten(in, tc.inBlinkOn)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %#v, got %#v", want, got)
+			}
+			if !reflect.DeepEqual(in.Cell[0], tc.inCell) {
+				t.Errorf("input modified")
+			}
+		})
+	}
+}
+
+func TestBlinkPhase(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+
+	tt := []struct {
+		name string
+		in   time.Time
+		want bool
+	}{
+		{
+			name: "On",
+			in:   base,
+			want: true,
+		},
+		{
+			name: "OnEnd",
+			in:   base.Add(BlinkInterval - time.Millisecond),
+			want: true,
+		},
+		{
+			name: "Off",
+			in:   base.Add(BlinkInterval),
+			want: false,
+		},
+		{
+			name: "OnAgain",
+			in:   base.Add(2 * BlinkInterval),
+			want: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := BlinkPhase(tc.in)
+			if got != tc.want {
+				t.Errorf("expected %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
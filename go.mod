@@ -1,6 +1,6 @@
 module github.com/gcrtnst/sw-term-server
 
-go 1.20
+go 1.21
 
 require (
 	golang.org/x/sys v0.5.0
@@ -0,0 +1,53 @@
+#ifndef __CGO_VTERM_OUTPUT_H__
+#define __CGO_VTERM_OUTPUT_H__
+
+#include <stdlib.h>
+#include <string.h>
+#include <vterm.h>
+
+// Collects everything libvterm writes during one call, so that replies
+// larger than libvterm's own output buffer are not cut short.
+typedef struct {
+  char *buf;
+  size_t len;
+  size_t cap;
+  int failed;
+} CGoVTermOutput;
+
+static void cgo_vterm_output_write(const char *s, size_t len, void *user) {
+  CGoVTermOutput *o = user;
+
+  // After a failed allocation, len only counts the bytes to report as
+  // dropped.
+  if (o->failed) {
+    o->len += len;
+    return;
+  }
+  if (o->len + len > o->cap) {
+    size_t cap = o->cap > 0 ? o->cap : 4096;
+    while (cap < o->len + len) {
+      cap *= 2;
+    }
+    char *buf = realloc(o->buf, cap);
+    if (buf == NULL) {
+      o->failed = 1;
+      o->len += len;
+      return;
+    }
+    o->buf = buf;
+    o->cap = cap;
+  }
+  memcpy(o->buf + o->len, s, len);
+  o->len += len;
+}
+
+static void cgo_vterm_output_init(VTerm *vt, CGoVTermOutput *o) {
+  vterm_output_set_callback(vt, &cgo_vterm_output_write, o);
+}
+
+static void cgo_vterm_output_reset(CGoVTermOutput *o) {
+  o->len = 0;
+  o->failed = 0;
+}
+
+#endif
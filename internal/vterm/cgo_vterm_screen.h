@@ -2,15 +2,152 @@
 #define __CGO_VTERM_SCREEN_H__
 
 #include <stdbool.h>
+#include <string.h>
 #include <vterm.h>
 
+#define CGO_VTERM_TITLE_MAX 1024
+#define CGO_VTERM_DAMAGE_MAX 64
+#define CGO_VTERM_CLIPBOARD_MAX 65536
+#define CGO_VTERM_SELECTION_BUF 4096
+#define CGO_VTERM_EVENT_MAX 64
+
+#define CGO_VTERM_EVENT_BELL 1
+#define CGO_VTERM_EVENT_TITLE 2
+#define CGO_VTERM_EVENT_CLIPBOARD 3
+#define CGO_VTERM_EVENT_RESIZE 4
+
 typedef struct {
   VTermPos cursor_pos;
   int cursor_visible;
   int cursor_blink;
   int cursor_shape;
+  int mouse;
+  int altscreen;
+
+  char title[CGO_VTERM_TITLE_MAX];
+  size_t title_len;
+  char title_buf[CGO_VTERM_TITLE_MAX];
+  size_t title_buf_len;
+
+  VTermRect damage[CGO_VTERM_DAMAGE_MAX];
+  int damage_len;
+
+  VTermState *state;
+  char selection_buf[CGO_VTERM_SELECTION_BUF];
+  char clipboard[CGO_VTERM_CLIPBOARD_MAX];
+  size_t clipboard_len;
+  char clipboard_buf[CGO_VTERM_CLIPBOARD_MAX];
+  size_t clipboard_buf_len;
+
+  int event[CGO_VTERM_EVENT_MAX];
+  int event_head;
+  int event_len;
 } CGoVTermScreenUser;
 
+// When the queue is full, the oldest event is dropped.
+static void cgo_vterm_screen_user_pushevent(CGoVTermScreenUser *u, int ev) {
+  if (u->event_len >= CGO_VTERM_EVENT_MAX) {
+    u->event_head = (u->event_head + 1) % CGO_VTERM_EVENT_MAX;
+    u->event_len--;
+  }
+  u->event[(u->event_head + u->event_len) % CGO_VTERM_EVENT_MAX] = ev;
+  u->event_len++;
+}
+
+static int cgo_vterm_screen_user_event(CGoVTermScreenUser *u, int i) {
+  return u->event[(u->event_head + i) % CGO_VTERM_EVENT_MAX];
+}
+
+static int cgo_vterm_rect_touches(VTermRect a, VTermRect b) {
+  return a.start_row <= b.end_row && b.start_row <= a.end_row &&
+         a.start_col <= b.end_col && b.start_col <= a.end_col;
+}
+
+static VTermRect cgo_vterm_rect_union(VTermRect a, VTermRect b) {
+  VTermRect r = a;
+  if (b.start_row < r.start_row) {
+    r.start_row = b.start_row;
+  }
+  if (b.end_row > r.end_row) {
+    r.end_row = b.end_row;
+  }
+  if (b.start_col < r.start_col) {
+    r.start_col = b.start_col;
+  }
+  if (b.end_col > r.end_col) {
+    r.end_col = b.end_col;
+  }
+  return r;
+}
+
+// Rectangles that overlap or touch are merged, so that a run of updated
+// cells is reported as a single rectangle. When the list is full,
+// everything collapses into the bounding rectangle.
+static void cgo_vterm_screen_user_adddamage(CGoVTermScreenUser *u,
+                                            VTermRect rect) {
+  if (rect.start_row >= rect.end_row || rect.start_col >= rect.end_col) {
+    return;
+  }
+
+  int i = 0;
+  while (i < u->damage_len) {
+    if (cgo_vterm_rect_touches(rect, u->damage[i])) {
+      rect = cgo_vterm_rect_union(rect, u->damage[i]);
+      u->damage_len--;
+      u->damage[i] = u->damage[u->damage_len];
+      i = 0;
+      continue;
+    }
+    i++;
+  }
+
+  if (u->damage_len >= CGO_VTERM_DAMAGE_MAX) {
+    for (i = 0; i < u->damage_len; i++) {
+      rect = cgo_vterm_rect_union(rect, u->damage[i]);
+    }
+    u->damage_len = 0;
+  }
+
+  u->damage[u->damage_len] = rect;
+  u->damage_len++;
+}
+
+static void cgo_vterm_screen_user_settitle(CGoVTermScreenUser *u,
+                                           VTermStringFragment frag) {
+  if (frag.initial) {
+    u->title_buf_len = 0;
+  }
+
+  size_t len = frag.len;
+  if (len > CGO_VTERM_TITLE_MAX - u->title_buf_len) {
+    len = CGO_VTERM_TITLE_MAX - u->title_buf_len;
+  }
+  memcpy(u->title_buf + u->title_buf_len, frag.str, len);
+  u->title_buf_len += len;
+
+  if (frag.final) {
+    memcpy(u->title, u->title_buf, u->title_buf_len);
+    u->title_len = u->title_buf_len;
+    cgo_vterm_screen_user_pushevent(u, CGO_VTERM_EVENT_TITLE);
+  }
+}
+
+static int cgo_vterm_screen_user_damage(VTermRect rect, void *user) {
+  CGoVTermScreenUser *u = user;
+  cgo_vterm_screen_user_adddamage(u, rect);
+  return 1;
+}
+
+// The moved region now shows different content, so it is reported as
+// damage rather than as a move. Damage previously recorded inside src is
+// covered by dest or cleared by libvterm separately.
+static int cgo_vterm_screen_user_moverect(VTermRect dest, VTermRect src,
+                                          void *user) {
+  CGoVTermScreenUser *u = user;
+  cgo_vterm_screen_user_adddamage(u, dest);
+  return 1;
+}
+
 static int cgo_vterm_screen_user_movecursor(VTermPos pos, VTermPos oldpos,
                                             int visible, void *user) {
   CGoVTermScreenUser *u = user;
@@ -32,6 +169,15 @@ static int cgo_vterm_screen_user_settermprop(VTermProp prop, VTermValue *val,
   case VTERM_PROP_CURSORSHAPE:
     u->cursor_shape = val->number;
     break;
+  case VTERM_PROP_MOUSE:
+    u->mouse = val->number;
+    break;
+  case VTERM_PROP_ALTSCREEN:
+    u->altscreen = val->boolean;
+    break;
+  case VTERM_PROP_TITLE:
+    cgo_vterm_screen_user_settitle(u, val->string);
+    break;
   default:
     break;
   }
@@ -39,9 +185,78 @@ static int cgo_vterm_screen_user_settermprop(VTermProp prop, VTermValue *val,
   return 1;
 }
 
+static int cgo_vterm_screen_user_bell(void *user) {
+  CGoVTermScreenUser *u = user;
+  cgo_vterm_screen_user_pushevent(u, CGO_VTERM_EVENT_BELL);
+  return 1;
+}
+
+static int cgo_vterm_screen_user_resize(int rows, int cols, void *user) {
+  CGoVTermScreenUser *u = user;
+  cgo_vterm_screen_user_pushevent(u, CGO_VTERM_EVENT_RESIZE);
+  return 1;
+}
+
 VTermScreenCallbacks cgo_vterm_screen_user_callbacks = {
+    .damage = &cgo_vterm_screen_user_damage,
+    .moverect = &cgo_vterm_screen_user_moverect,
     .movecursor = &cgo_vterm_screen_user_movecursor,
     .settermprop = &cgo_vterm_screen_user_settermprop,
+    .bell = &cgo_vterm_screen_user_bell,
+    .resize = &cgo_vterm_screen_user_resize,
+};
+
+// All selection buffers (clipboard, primary, ...) share a single store.
+static int cgo_vterm_screen_user_selection_set(VTermSelectionMask mask,
+                                               VTermStringFragment frag,
+                                               void *user) {
+  CGoVTermScreenUser *u = user;
+
+  if (frag.initial) {
+    u->clipboard_buf_len = 0;
+  }
+
+  size_t len = frag.len;
+  if (len > CGO_VTERM_CLIPBOARD_MAX - u->clipboard_buf_len) {
+    len = CGO_VTERM_CLIPBOARD_MAX - u->clipboard_buf_len;
+  }
+  memcpy(u->clipboard_buf + u->clipboard_buf_len, frag.str, len);
+  u->clipboard_buf_len += len;
+
+  if (frag.final) {
+    memcpy(u->clipboard, u->clipboard_buf, u->clipboard_buf_len);
+    u->clipboard_len = u->clipboard_buf_len;
+    cgo_vterm_screen_user_pushevent(u, CGO_VTERM_EVENT_CLIPBOARD);
+  }
+  return 1;
+}
+
+static int cgo_vterm_screen_user_selection_query(VTermSelectionMask mask,
+                                                 void *user) {
+  CGoVTermScreenUser *u = user;
+
+  size_t off = 0;
+  do {
+    size_t len = u->clipboard_len - off;
+    if (len > CGO_VTERM_SELECTION_BUF) {
+      len = CGO_VTERM_SELECTION_BUF;
+    }
+
+    VTermStringFragment frag = {
+        .str = u->clipboard + off,
+        .len = len,
+        .initial = off == 0,
+        .final = off + len == u->clipboard_len,
+    };
+    vterm_state_send_selection(u->state, mask, frag);
+    off += len;
+  } while (off < u->clipboard_len);
+  return 1;
+}
+
+VTermSelectionCallbacks cgo_vterm_screen_user_selection_callbacks = {
+    .set = &cgo_vterm_screen_user_selection_set,
+    .query = &cgo_vterm_screen_user_selection_query,
 };
 
 static unsigned int cgo_vterm_screen_attrs_bold(VTermScreenCellAttrs attrs) {
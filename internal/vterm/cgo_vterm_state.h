@@ -0,0 +1,130 @@
+#ifndef __CGO_VTERM_STATE_H__
+#define __CGO_VTERM_STATE_H__
+
+#include <string.h>
+#include <vterm.h>
+
+#define CGO_VTERM_SEQ_OSC 1
+#define CGO_VTERM_SEQ_DCS 2
+#define CGO_VTERM_SEQ_MAX 32
+#define CGO_VTERM_SEQ_DATA_MAX 1024
+#define CGO_VTERM_SEQ_COMMAND_MAX 16
+#define CGO_VTERM_OSC_ALLOW_MAX 32
+
+typedef struct {
+  int kind;
+  int command;
+  char dcs_command[CGO_VTERM_SEQ_COMMAND_MAX];
+  size_t dcs_command_len;
+  char data[CGO_VTERM_SEQ_DATA_MAX];
+  size_t data_len;
+} CGoVTermSeq;
+
+typedef struct {
+  int osc_allow[CGO_VTERM_OSC_ALLOW_MAX];
+  int osc_allow_len;
+  int dcs_allow;
+
+  CGoVTermSeq cur;
+  CGoVTermSeq seq[CGO_VTERM_SEQ_MAX];
+  int seq_head;
+  int seq_len;
+} CGoVTermStateUser;
+
+static unsigned int
+cgo_vterm_lineinfo_doublewidth(const VTermLineInfo *info) {
+  return info->doublewidth;
+}
+
+static unsigned int
+cgo_vterm_lineinfo_doubleheight(const VTermLineInfo *info) {
+  return info->doubleheight;
+}
+
+static unsigned int
+cgo_vterm_lineinfo_continuation(const VTermLineInfo *info) {
+  return info->continuation;
+}
+
+static CGoVTermSeq *cgo_vterm_state_user_seq(CGoVTermStateUser *u, int i) {
+  return &u->seq[(u->seq_head + i) % CGO_VTERM_SEQ_MAX];
+}
+
+// When the queue is full, the oldest sequence is dropped.
+static void cgo_vterm_state_user_push(CGoVTermStateUser *u) {
+  if (u->seq_len >= CGO_VTERM_SEQ_MAX) {
+    u->seq_head = (u->seq_head + 1) % CGO_VTERM_SEQ_MAX;
+    u->seq_len--;
+  }
+  *cgo_vterm_state_user_seq(u, u->seq_len) = u->cur;
+  u->seq_len++;
+}
+
+static void cgo_vterm_state_user_append(CGoVTermStateUser *u, int kind,
+                                        int command, const char *dcs_command,
+                                        size_t dcs_command_len,
+                                        VTermStringFragment frag) {
+  CGoVTermSeq *cur = &u->cur;
+
+  if (frag.initial) {
+    if (dcs_command_len > CGO_VTERM_SEQ_COMMAND_MAX) {
+      dcs_command_len = CGO_VTERM_SEQ_COMMAND_MAX;
+    }
+    cur->kind = kind;
+    cur->command = command;
+    if (dcs_command_len > 0) {
+      memcpy(cur->dcs_command, dcs_command, dcs_command_len);
+    }
+    cur->dcs_command_len = dcs_command_len;
+    cur->data_len = 0;
+  }
+
+  size_t len = frag.len;
+  if (len > CGO_VTERM_SEQ_DATA_MAX - cur->data_len) {
+    len = CGO_VTERM_SEQ_DATA_MAX - cur->data_len;
+  }
+  memcpy(cur->data + cur->data_len, frag.str, len);
+  cur->data_len += len;
+
+  if (frag.final) {
+    cgo_vterm_state_user_push(u);
+  }
+}
+
+static int cgo_vterm_state_user_osc(int command, VTermStringFragment frag,
+                                    void *user) {
+  CGoVTermStateUser *u = user;
+
+  int allowed = 0;
+  for (int i = 0; i < u->osc_allow_len; i++) {
+    if (u->osc_allow[i] == command) {
+      allowed = 1;
+      break;
+    }
+  }
+  if (!allowed) {
+    return 0;
+  }
+
+  cgo_vterm_state_user_append(u, CGO_VTERM_SEQ_OSC, command, NULL, 0, frag);
+  return 1;
+}
+
+static int cgo_vterm_state_user_dcs(const char *command, size_t commandlen,
+                                    VTermStringFragment frag, void *user) {
+  CGoVTermStateUser *u = user;
+  if (!u->dcs_allow) {
+    return 0;
+  }
+
+  cgo_vterm_state_user_append(u, CGO_VTERM_SEQ_DCS, 0, command, commandlen,
+                              frag);
+  return 1;
+}
+
+VTermStateFallbacks cgo_vterm_state_user_fallbacks = {
+    .osc = &cgo_vterm_state_user_osc,
+    .dcs = &cgo_vterm_state_user_dcs,
+};
+
+#endif
@@ -67,9 +67,11 @@ const (
 	KeyMax         Key = C.VTERM_KEY_MAX
 )
 
-func (vt *VTerm) KeyboardRune(r rune, mod Modifier) {
+// KeyboardRune sends r to the application. It returns ErrOutputFull if the
+// input was dropped because Output is full.
+func (vt *VTerm) KeyboardRune(r rune, mod Modifier) error {
 	if r < 0 || unicode.MaxRune < r {
-		return
+		return nil
 	}
 
 	vt.mu.Lock()
@@ -79,12 +81,14 @@ func (vt *VTerm) KeyboardRune(r rune, mod Modifier) {
 	c_mod := C.VTermModifier(mod & ModAll)
 	C.vterm_keyboard_unichar(vt.vt, c_c, c_mod)
 
-	vt.flush()
+	return vt.flush()
 }
 
-func (vt *VTerm) KeyboardKey(key Key, mod Modifier) {
+// KeyboardKey sends key to the application. Like KeyboardRune, it returns
+// ErrOutputFull if the input was dropped.
+func (vt *VTerm) KeyboardKey(key Key, mod Modifier) error {
 	if key < KeyNone || KeyMax < key {
-		return
+		return nil
 	}
 
 	vt.mu.Lock()
@@ -94,5 +98,5 @@ func (vt *VTerm) KeyboardKey(key Key, mod Modifier) {
 	c_mod := C.VTermModifier(mod & ModAll)
 	C.vterm_keyboard_key(vt.vt, c_key, c_mod)
 
-	vt.flush()
+	return vt.flush()
 }
@@ -0,0 +1 @@
+#include "libvterm/src/screen.c"
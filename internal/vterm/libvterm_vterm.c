@@ -0,0 +1 @@
+#include "libvterm/src/vterm.c"
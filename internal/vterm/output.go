@@ -1,43 +1,141 @@
 package vterm
 
+// #include <stdlib.h>
+// #include <string.h>
 // #include <vterm.h>
+// #include <cgo_vterm_output.h>
 import "C"
 import (
+	"errors"
 	"io"
+	"sync"
 	"unsafe"
 )
 
+var ErrOutputFull = errors.New("vterm: output buffer full")
+
+// OutputBufferSize is the maximum number of bytes held by Output before
+// it is read. It is large enough for an OSC 52 reply carrying a full
+// clipboard.
+const OutputBufferSize = 128 << 10
+
+// Output buffers the bytes libvterm sends to the application. Flushing
+// never blocks, so a reader that stops reading cannot hold up the VTerm
+// lock. When the buffer is full, newly flushed chunks are dropped whole so
+// that escape sequences are never split.
 type Output struct {
-	r *io.PipeReader
-	w *io.PipeWriter
+	c       *C.CGoVTermOutput
+	mu      sync.Mutex
+	cond    *sync.Cond
+	buf     []byte
+	closed  bool
+	dropped uint64
 }
 
 func newOutput() *Output {
-	r, w := io.Pipe()
-	return &Output{r: r, w: w}
+	out := &Output{}
+	out.cond = sync.NewCond(&out.mu)
+	return out
 }
 
+// Read blocks until buffered data is available. After Close, the remaining
+// data is returned, followed by io.EOF.
 func (out *Output) Read(p []byte) (int, error) {
-	return out.r.Read(p)
+	out.mu.Lock()
+	defer out.mu.Unlock()
+
+	for len(out.buf) <= 0 && !out.closed {
+		out.cond.Wait()
+	}
+	if len(out.buf) <= 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, out.buf)
+	out.buf = out.buf[n:]
+	if len(out.buf) <= 0 {
+		out.buf = nil
+	}
+	return n, nil
 }
 
 func (out *Output) Close() error {
-	return out.w.Close()
+	out.mu.Lock()
+	defer out.mu.Unlock()
+
+	out.closed = true
+	out.cond.Broadcast()
+	return nil
+}
+
+// Dropped returns the number of bytes discarded because the buffer was full.
+func (out *Output) Dropped() uint64 {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+
+	return out.dropped
+}
+
+func (out *Output) init(vt *C.VTerm) {
+	c_out := C.malloc(C.sizeof_CGoVTermOutput)
+	_ = C.memset(c_out, 0, C.sizeof_CGoVTermOutput)
+
+	out.c = (*C.CGoVTermOutput)(c_out)
+	C.cgo_vterm_output_init(vt, out.c)
+}
+
+func (out *Output) free(vt *C.VTerm) {
+	C.vterm_output_set_callback(vt, nil, nil)
+	C.free(unsafe.Pointer(out.c.buf))
+	C.free(unsafe.Pointer(out.c))
+	out.c = nil
 }
 
-func (out *Output) flush(vt *C.VTerm) {
-	cur := C.vterm_output_get_buffer_current(vt)
-	if cur <= 0 {
-		return
+// flush moves the bytes libvterm produced since the last flush into the
+// buffer as a single chunk. It returns ErrOutputFull if they were dropped.
+func (out *Output) flush() error {
+	buf, ok := out.take()
+	if !ok {
+		return ErrOutputFull
+	}
+	if len(buf) <= 0 {
+		return nil
 	}
 
-	buf := make([]byte, cur)
-	ptr := (*C.char)(unsafe.Pointer(&buf[0]))
-	got := C.vterm_output_read(vt, ptr, cur)
-	if got <= 0 {
-		return
+	return out.write(buf)
+}
+
+// take returns the bytes libvterm produced since the last call and clears
+// them. If they could not all be collected, they are counted as dropped and
+// ok is false.
+func (out *Output) take() (buf []byte, ok bool) {
+	defer C.cgo_vterm_output_reset(out.c)
+
+	if out.c.len <= 0 {
+		return nil, true
+	}
+	if out.c.failed != 0 {
+		out.mu.Lock()
+		out.dropped += uint64(out.c.len)
+		out.mu.Unlock()
+		return nil, false
+	}
+	return C.GoBytes(unsafe.Pointer(out.c.buf), C.int(out.c.len)), true
+}
+
+func (out *Output) write(p []byte) error {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+
+	if out.closed {
+		return nil
+	}
+	if len(out.buf)+len(p) > OutputBufferSize {
+		out.dropped += uint64(len(p))
+		return ErrOutputFull
 	}
-	buf = buf[:got]
 
-	_, _ = out.w.Write(buf)
+	out.buf = append(out.buf, p...)
+	out.cond.Broadcast()
+	return nil
 }
@@ -2,6 +2,7 @@ package vterm
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"testing"
 )
@@ -52,3 +53,52 @@ func TestOutputClose(t *testing.T) {
 		t.Errorf("errRead: expected io.EOF, got %#v", errRead)
 	}
 }
+
+func TestOutputFull(t *testing.T) {
+	vt := New(30, 120)
+	out := vt.Output()
+
+	chunk := bytes.Repeat([]byte("a"), 1000)
+	n := OutputBufferSize / len(chunk)
+	for i := 0; i < n+1; i++ {
+		out.write(chunk)
+	}
+	_ = out.Close()
+
+	gotP, gotErr := io.ReadAll(out)
+	wantP := bytes.Repeat(chunk, n)
+	if !bytes.Equal(gotP, wantP) {
+		t.Errorf("p: expected %d bytes, got %d bytes", len(wantP), len(gotP))
+	}
+	if gotErr != nil {
+		t.Errorf("err: %v", gotErr)
+	}
+
+	gotDropped := out.Dropped()
+	wantDropped := uint64(len(chunk))
+	if gotDropped != wantDropped {
+		t.Errorf("dropped: expected %d, got %d", wantDropped, gotDropped)
+	}
+}
+
+func TestOutputNoReader(t *testing.T) {
+	vt := New(30, 120)
+	out := vt.Output()
+
+	// Must not block even though nothing reads from out.
+	var err error
+	for i := 0; i < OutputBufferSize+1; i++ {
+		err = vt.KeyboardRune('a', ModNone)
+	}
+	if out.Dropped() <= 0 {
+		t.Errorf("dropped: expected non-zero")
+	}
+	if !errors.Is(err, ErrOutputFull) {
+		t.Errorf("err: expected %#v, got %#v", ErrOutputFull, err)
+	}
+
+	err = vt.KeyboardKey(KeyEnter, ModNone)
+	if !errors.Is(err, ErrOutputFull) {
+		t.Errorf("key err: expected %#v, got %#v", ErrOutputFull, err)
+	}
+}
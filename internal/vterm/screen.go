@@ -5,7 +5,14 @@ package vterm
 // #include <vterm.h>
 // #include <cgo_vterm_screen.h>
 import "C"
-import "unicode"
+import (
+	"strings"
+	"unicode"
+	"unsafe"
+)
+
+// ClipboardMax is the maximum number of bytes held in the clipboard.
+const ClipboardMax = C.CGO_VTERM_CLIPBOARD_MAX
 
 type Screen struct {
 	vt *VTerm
@@ -49,6 +56,14 @@ func (scr *Screen) SetPaletteColor(index byte, col Color) {
 	C.vterm_state_set_palette_color(c_state, c_index, &c_col);
 }
 
+func (scr *Screen) DefaultColor() (Color, Color) {
+	return scr.vt.State().DefaultColor()
+}
+
+func (scr *Screen) PaletteColor(index byte) Color {
+	return scr.vt.State().PaletteColor(index)
+}
+
 func (scr *Screen) Capture() ScreenShot {
 	scr.vt.mu.Lock()
 	defer scr.vt.mu.Unlock()
@@ -107,6 +122,79 @@ func (scr *Screen) CursorShape() CursorShape {
 	return CursorShape(c_user.cursor_shape)
 }
 
+func (scr *Screen) Title() string {
+	scr.vt.mu.Lock()
+	defer scr.vt.mu.Unlock()
+
+	c_user := scr.cbdata()
+	c_len := C.int(c_user.title_len)
+	return C.GoStringN(&c_user.title[0], c_len)
+}
+
+// Clipboard returns the selection most recently set by the application
+// through OSC 52 or by SetClipboard.
+func (scr *Screen) Clipboard() []byte {
+	scr.vt.mu.Lock()
+	defer scr.vt.mu.Unlock()
+
+	c_user := scr.cbdata()
+	c_len := C.int(c_user.clipboard_len)
+	return C.GoBytes(unsafe.Pointer(&c_user.clipboard[0]), c_len)
+}
+
+// SetClipboard replaces the selection returned to OSC 52 queries. Data
+// beyond the clipboard capacity is dropped.
+func (scr *Screen) SetClipboard(b []byte) {
+	scr.vt.mu.Lock()
+	defer scr.vt.mu.Unlock()
+
+	if len(b) > ClipboardMax {
+		b = b[:ClipboardMax]
+	}
+
+	c_user := scr.cbdata()
+	if len(b) > 0 {
+		_ = C.memcpy(unsafe.Pointer(&c_user.clipboard[0]), unsafe.Pointer(&b[0]), C.size_t(len(b)))
+	}
+	c_user.clipboard_len = C.size_t(len(b))
+}
+
+// TakeEvents returns the events raised since the previous call and clears
+// them. Only the most recent 64 events are kept.
+func (scr *Screen) TakeEvents() []ScreenEvent {
+	scr.vt.mu.Lock()
+	defer scr.vt.mu.Unlock()
+
+	c_user := scr.cbdata()
+	n := int(c_user.event_len)
+	events := make([]ScreenEvent, n)
+	for i := 0; i < n; i++ {
+		events[i] = ScreenEvent(C.cgo_vterm_screen_user_event(c_user, C.int(i)))
+	}
+	c_user.event_head = 0
+	c_user.event_len = 0
+	return events
+}
+
+// TakeDamage returns the regions changed since the previous call and
+// clears them.
+func (scr *Screen) TakeDamage() []Rect {
+	scr.vt.mu.Lock()
+	defer scr.vt.mu.Unlock()
+
+	c_screen := scr.obtain()
+	C.vterm_screen_flush_damage(c_screen)
+
+	c_user := scr.cbdata()
+	n := int(c_user.damage_len)
+	damage := make([]Rect, n)
+	for i := 0; i < n; i++ {
+		damage[i] = newRectFromC(c_user.damage[i])
+	}
+	c_user.damage_len = 0
+	return damage
+}
+
 func (scr *Screen) ConvertColorToRGB(col Color) Color {
 	scr.vt.mu.Lock()
 	defer scr.vt.mu.Unlock()
@@ -166,6 +254,11 @@ func (scr *Screen) convertColorToRGB(col Color) Color {
 	return newColorFromC(c_col)
 }
 
+func (scr *Screen) modes() (MouseMode, bool) {
+	c_user := scr.cbdata()
+	return MouseMode(c_user.mouse), c_user.altscreen != 0
+}
+
 func (scr *Screen) cbdata() *C.CGoVTermScreenUser {
 	c_screen := scr.obtain()
 	c_user := C.vterm_screen_get_cbdata(c_screen)
@@ -180,12 +273,19 @@ func (scr *Screen) init() {
 	C.vterm_screen_set_callbacks(c_screen, &C.cgo_vterm_screen_user_callbacks, c_user)
 	C.vterm_screen_set_damage_merge(c_screen, C.VTERM_DAMAGE_CELL)
 	C.vterm_screen_reset(c_screen, 1)
+
+	c_state := C.vterm_obtain_state(scr.vt.vt)
+	c_u := (*C.CGoVTermScreenUser)(c_user)
+	c_u.state = c_state
+	C.vterm_state_set_selection_callbacks(c_state, &C.cgo_vterm_screen_user_selection_callbacks, c_user, &c_u.selection_buf[0], C.CGO_VTERM_SELECTION_BUF)
 }
 
 func (scr *Screen) free() {
 	c_screen := scr.obtain()
 	c_user := C.vterm_screen_get_cbdata(c_screen)
 	C.vterm_screen_set_callbacks(c_screen, nil, nil)
+	c_state := C.vterm_obtain_state(scr.vt.vt)
+	C.vterm_state_set_selection_callbacks(c_state, nil, nil, nil, 0)
 	C.free(c_user)
 }
 
@@ -227,6 +327,45 @@ func (ss ScreenShot) At(pos Pos) Cell {
 	return ss.Cell[idx]
 }
 
+// Text returns the characters inside rect, one line per row with trailing
+// spaces removed. The rectangle is clipped to the screenshot.
+func (ss ScreenShot) Text(rect Rect) string {
+	rows, cols := ss.Size()
+	rect.StartRow = clamp(rect.StartRow, 0, rows)
+	rect.EndRow = clamp(rect.EndRow, 0, rows)
+	rect.StartCol = clamp(rect.StartCol, 0, cols)
+	rect.EndCol = clamp(rect.EndCol, 0, cols)
+
+	lines := make([]string, 0, rect.EndRow-rect.StartRow)
+	for row := rect.StartRow; row < rect.EndRow; row++ {
+		var line []rune
+		for col := rect.StartCol; col < rect.EndCol; col++ {
+			cell := ss.At(Pos{Row: row, Col: col})
+			if len(cell.Runes) == 0 {
+				line = append(line, ' ')
+				continue
+			}
+
+			line = append(line, cell.Runes...)
+			if cell.Width > 1 {
+				col += cell.Width - 1
+			}
+		}
+		lines = append(lines, strings.TrimRight(string(line), " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func clamp(x, min, max int) int {
+	if x < min {
+		return min
+	}
+	if x > max {
+		return max
+	}
+	return x
+}
+
 type Cell struct {
 	Runes  []rune
 	Width  int
@@ -312,6 +451,15 @@ const (
 	BaselineLower  Baseline = C.VTERM_BASELINE_LOWER
 )
 
+type ScreenEvent uint8
+
+const (
+	ScreenEventBell      ScreenEvent = C.CGO_VTERM_EVENT_BELL
+	ScreenEventTitle     ScreenEvent = C.CGO_VTERM_EVENT_TITLE
+	ScreenEventClipboard ScreenEvent = C.CGO_VTERM_EVENT_CLIPBOARD
+	ScreenEventResize    ScreenEvent = C.CGO_VTERM_EVENT_RESIZE
+)
+
 type CursorShape uint8
 
 const (
@@ -1,7 +1,10 @@
 package vterm
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
@@ -210,6 +213,40 @@ func TestScreenSetPaletteColor(t *testing.T) {
 	}
 }
 
+func TestScreenDefaultColor(t *testing.T) {
+	inFG := NewColorRGB(1, 2, 3)
+	inBG := NewColorRGB(4, 5, 6)
+
+	vt := New(30, 120)
+	_ = vt.Output().Close()
+	scr := vt.Screen()
+	scr.SetDefaultColor(inFG, inBG)
+	gotFG, gotBG := scr.DefaultColor()
+	if !gotFG.IsRGB() || gotFG.Red != 1 || gotFG.Green != 2 || gotFG.Blue != 3 {
+		t.Errorf("fg: expected %#v, got %#v", inFG, gotFG)
+	}
+	if !gotBG.IsRGB() || gotBG.Red != 4 || gotBG.Green != 5 || gotBG.Blue != 6 {
+		t.Errorf("bg: expected %#v, got %#v", inBG, gotBG)
+	}
+}
+
+func TestScreenPaletteColor(t *testing.T) {
+	vt := New(30, 120)
+	_ = vt.Output().Close()
+	scr := vt.Screen()
+
+	for idx := 0; idx < 16; idx++ {
+		scr.SetPaletteColor(byte(idx), NewColorRGB(byte(idx), byte(idx), byte(idx)))
+	}
+	for idx := 0; idx < 16; idx++ {
+		want := NewColorRGB(byte(idx), byte(idx), byte(idx))
+		got := scr.PaletteColor(byte(idx))
+		if !got.Equal(want) {
+			t.Errorf("%d: expected %#v, got %#v", idx, want, got)
+		}
+	}
+}
+
 func TestScreenCapture(t *testing.T) {
 	fg := NewColorIndexed(7)
 	fg.Type |= ColorDefaultFG
@@ -752,6 +789,83 @@ func TestScreenCell(t *testing.T) {
 	}
 }
 
+func TestScreenWideCharAtEdge(t *testing.T) {
+	tt := []struct {
+		name       string
+		inRow      int
+		inCol      int
+		inIn       string
+		wantText   string
+		wantCursor Pos
+	}{
+		{
+			name:       "OneColumn",
+			inRow:      2,
+			inCol:      1,
+			inIn:       "\u3042",
+			wantText:   "\n\u3042",
+			wantCursor: Pos{Row: 1, Col: 0},
+		},
+		{
+			name:       "OneColumnRepeat",
+			inRow:      2,
+			inCol:      1,
+			inIn:       "\u3042\u3044",
+			wantText:   "\u3042\n\u3044",
+			wantCursor: Pos{Row: 1, Col: 0},
+		},
+		{
+			name:       "LastColumn",
+			inRow:      2,
+			inCol:      3,
+			inIn:       "AB\u3042",
+			wantText:   "AB\n\u3042",
+			wantCursor: Pos{Row: 1, Col: 2},
+		},
+		{
+			name:       "LastColumnNoAutoWrap",
+			inRow:      2,
+			inCol:      3,
+			inIn:       "\x1B[?7lAB\u3042",
+			wantText:   "AB\n\u3042",
+			wantCursor: Pos{Row: 1, Col: 2},
+		},
+		{
+			name:       "LastColumnScroll",
+			inRow:      2,
+			inCol:      3,
+			inIn:       "\x1B[2;3H\u3042",
+			wantText:   "\n\u3042",
+			wantCursor: Pos{Row: 1, Col: 2},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			vt := New(tc.inRow, tc.inCol)
+			_ = vt.Output().Close()
+			vt.SetUTF8(true)
+			_, _ = vt.Input().Write([]byte(tc.inIn))
+			ss := vt.Screen().Capture()
+
+			for i, cell := range ss.Cell {
+				if col := i % ss.Stride; col+cell.Width > ss.Stride {
+					t.Errorf("cell %d: width %d exceeds the right edge", i, cell.Width)
+				}
+			}
+
+			gotText := ss.Text(Rect{StartRow: 0, EndRow: tc.inRow, StartCol: 0, EndCol: tc.inCol})
+			if gotText != tc.wantText {
+				t.Errorf("text: expected %#v, got %#v", tc.wantText, gotText)
+			}
+			if ss.CursorPos != tc.wantCursor {
+				t.Errorf("cursor: expected %#v, got %#v", tc.wantCursor, ss.CursorPos)
+			}
+		})
+	}
+}
+
 func TestScreenCursorPos(t *testing.T) {
 	vt := New(30, 120)
 	_ = vt.Output().Close()
@@ -839,6 +953,207 @@ func TestScreenCursorShape(t *testing.T) {
 	}
 }
 
+func TestScreenTitle(t *testing.T) {
+	vt := New(30, 120)
+	_ = vt.Output().Close()
+	in := vt.Input()
+	scr := vt.Screen()
+
+	want := ""
+	got := scr.Title()
+	if got != want {
+		t.Errorf("init: expected %#v, got %#v", want, got)
+	}
+
+	_, _ = in.Write([]byte("\x1B]2;hello\x07"))
+	want = "hello"
+	got = scr.Title()
+	if got != want {
+		t.Errorf("set1: expected %#v, got %#v", want, got)
+	}
+
+	_, _ = in.Write([]byte("\x1B]0;wor"))
+	want = "hello"
+	got = scr.Title()
+	if got != want {
+		t.Errorf("partial: expected %#v, got %#v", want, got)
+	}
+
+	_, _ = in.Write([]byte("ld\x1B\\"))
+	want = "world"
+	got = scr.Title()
+	if got != want {
+		t.Errorf("set2: expected %#v, got %#v", want, got)
+	}
+
+	_, _ = in.Write([]byte("\x1B]2;" + strings.Repeat("A", 2000) + "\x07"))
+	want = strings.Repeat("A", 1024)
+	got = scr.Title()
+	if got != want {
+		t.Errorf("long: expected %d bytes, got %d bytes", len(want), len(got))
+	}
+}
+
+func TestScreenTakeDamage(t *testing.T) {
+	vt := New(3, 10)
+	_ = vt.Output().Close()
+	in := vt.Input()
+	scr := vt.Screen()
+
+	want := []Rect{{StartRow: 0, EndRow: 3, StartCol: 0, EndCol: 10}}
+	got := scr.TakeDamage()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("init: expected %#v, got %#v", want, got)
+	}
+
+	want = []Rect{}
+	got = scr.TakeDamage()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("clear: expected %#v, got %#v", want, got)
+	}
+
+	_, _ = in.Write([]byte("AB"))
+	want = []Rect{{StartRow: 0, EndRow: 1, StartCol: 0, EndCol: 2}}
+	got = scr.TakeDamage()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("write: expected %#v, got %#v", want, got)
+	}
+
+	_, _ = in.Write([]byte("\x1B[1;1HA\x1B[3;10HB"))
+	want = []Rect{
+		{StartRow: 0, EndRow: 1, StartCol: 0, EndCol: 1},
+		{StartRow: 2, EndRow: 3, StartCol: 9, EndCol: 10},
+	}
+	got = scr.TakeDamage()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("separate: expected %#v, got %#v", want, got)
+	}
+
+	_, _ = in.Write([]byte("\r\n"))
+	want = []Rect{{StartRow: 0, EndRow: 3, StartCol: 0, EndCol: 10}}
+	got = scr.TakeDamage()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scroll: expected %#v, got %#v", want, got)
+	}
+}
+
+func TestScreenTakeEvents(t *testing.T) {
+	vt := New(3, 10)
+	_ = vt.Output().Close()
+	in := vt.Input()
+	scr := vt.Screen()
+
+	want := []ScreenEvent{}
+	got := scr.TakeEvents()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("init: expected %#v, got %#v", want, got)
+	}
+
+	_, _ = in.Write([]byte("\x07\x1B]2;title\x07\x1B]52;c;aGVsbG8=\x07"))
+	want = []ScreenEvent{ScreenEventBell, ScreenEventTitle, ScreenEventClipboard}
+	got = scr.TakeEvents()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("write: expected %#v, got %#v", want, got)
+	}
+
+	vt.SetSize(4, 12)
+	want = []ScreenEvent{ScreenEventResize}
+	got = scr.TakeEvents()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resize: expected %#v, got %#v", want, got)
+	}
+
+	for i := 0; i < 100; i++ {
+		_, _ = in.Write([]byte("\x07"))
+	}
+	got = scr.TakeEvents()
+	if len(got) != 64 {
+		t.Errorf("overflow: expected 64 events, got %d", len(got))
+	}
+}
+
+func TestScreenClipboard(t *testing.T) {
+	vt := New(30, 120)
+	out := vt.Output()
+	in := vt.Input()
+	scr := vt.Screen()
+
+	want := []byte{}
+	got := scr.Clipboard()
+	if !bytes.Equal(got, want) {
+		t.Errorf("init: expected %#v, got %#v", want, got)
+	}
+
+	_, _ = in.Write([]byte("\x1B]52;c;aGVsbG8=\x07"))
+	want = []byte("hello")
+	got = scr.Clipboard()
+	if !bytes.Equal(got, want) {
+		t.Errorf("osc: expected %#v, got %#v", want, got)
+	}
+
+	scr.SetClipboard([]byte("world"))
+	want = []byte("world")
+	got = scr.Clipboard()
+	if !bytes.Equal(got, want) {
+		t.Errorf("set: expected %#v, got %#v", want, got)
+	}
+
+	go func() {
+		_, _ = in.Write([]byte("\x1B]52;c;?\x07"))
+		_ = out.Close()
+	}()
+	reply, _ := io.ReadAll(out)
+	if !bytes.HasPrefix(reply, []byte("\x1B]52;")) || !bytes.Contains(reply, []byte("d29ybGQ=")) {
+		t.Errorf("query: unexpected reply %#v", string(reply))
+	}
+}
+
+func TestScreenClipboardQuery(t *testing.T) {
+	tt := []struct {
+		name   string
+		inSize int
+	}{
+		{name: "Small", inSize: 5},
+		{name: "Large", inSize: 5000},
+		{name: "Max", inSize: ClipboardMax},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			vt := New(30, 120)
+			out := vt.Output()
+			in := vt.Input()
+			scr := vt.Screen()
+
+			clip := bytes.Repeat([]byte("0123456789"), tc.inSize/10+1)[:tc.inSize]
+			scr.SetClipboard(clip)
+
+			go func() {
+				_, _ = in.Write([]byte("\x1B]52;c;?\x07"))
+				_ = out.Close()
+			}()
+			reply, _ := io.ReadAll(out)
+
+			prefix := []byte("\x1B]52;c;")
+			suffix := []byte("\x1B\\")
+			if !bytes.HasPrefix(reply, prefix) || !bytes.HasSuffix(reply, suffix) {
+				t.Fatalf("reply: unexpected framing in %d bytes", len(reply))
+			}
+			got, err := base64.StdEncoding.DecodeString(string(reply[len(prefix) : len(reply)-len(suffix)]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, clip) {
+				t.Errorf("clipboard: expected %d bytes, got %d bytes", len(clip), len(got))
+			}
+			if dropped := out.Dropped(); dropped != 0 {
+				t.Errorf("dropped: expected 0, got %d", dropped)
+			}
+		})
+	}
+}
+
 func TestScreenConvertColorToRGB(t *testing.T) {
 	tt := []struct {
 		name string
@@ -1054,3 +1369,61 @@ func TestScreenShotAt(t *testing.T) {
 		})
 	}
 }
+
+func TestScreenShotText(t *testing.T) {
+	ss := ScreenShot{
+		Stride: 4,
+		Cell: []Cell{
+			{Runes: []rune{'A'}, Width: 1},
+			{Runes: []rune{'B'}, Width: 1},
+			{Runes: []rune{}, Width: 1},
+			{Runes: []rune{'C'}, Width: 1},
+			{Runes: []rune{'\u3042'}, Width: 2},
+			{Runes: []rune{}, Width: 1},
+			{Runes: []rune{'D'}, Width: 1},
+			{Runes: []rune{}, Width: 1},
+		},
+	}
+
+	tt := []struct {
+		name   string
+		inRect Rect
+		want   string
+	}{
+		{
+			name:   "All",
+			inRect: Rect{StartRow: 0, EndRow: 2, StartCol: 0, EndCol: 4},
+			want:   "AB C\n\u3042D",
+		},
+		{
+			name:   "Inner",
+			inRect: Rect{StartRow: 0, EndRow: 1, StartCol: 1, EndCol: 3},
+			want:   "B",
+		},
+		{
+			name:   "WideAtEnd",
+			inRect: Rect{StartRow: 1, EndRow: 2, StartCol: 0, EndCol: 1},
+			want:   "\u3042",
+		},
+		{
+			name:   "Clipped",
+			inRect: Rect{StartRow: -1, EndRow: 5, StartCol: 2, EndCol: 9},
+			want:   " C\nD",
+		},
+		{
+			name:   "Empty",
+			inRect: Rect{StartRow: 1, EndRow: 1, StartCol: 0, EndCol: 4},
+			want:   "",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := ss.Text(tc.inRect)
+			if got != tc.want {
+				t.Errorf("expected %#v, got %#v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package vterm
+
+// #include <stdlib.h>
+// #include <string.h>
+// #include <vterm.h>
+// #include <cgo_vterm_state.h>
+import "C"
+import (
+	"bytes"
+	"unsafe"
+)
+
+type MouseMode int
+
+const (
+	MouseNone  MouseMode = C.VTERM_PROP_MOUSE_NONE
+	MouseClick MouseMode = C.VTERM_PROP_MOUSE_CLICK
+	MouseDrag  MouseMode = C.VTERM_PROP_MOUSE_DRAG
+	MouseMove  MouseMode = C.VTERM_PROP_MOUSE_MOVE
+)
+
+type LineInfo struct {
+	DoubleWidth  bool
+	DoubleHeight uint8
+	Continuation bool
+}
+
+type Modes struct {
+	Mouse          MouseMode
+	AltScreen      bool
+	Keypad         bool
+	CursorKeys     bool
+	BracketedPaste bool
+}
+
+type SequenceKind uint8
+
+const (
+	SequenceOSC SequenceKind = C.CGO_VTERM_SEQ_OSC
+	SequenceDCS SequenceKind = C.CGO_VTERM_SEQ_DCS
+)
+
+// Sequence is an OSC or DCS string that libvterm did not handle itself.
+// Command is the OSC number; DCSCommand holds the DCS intermediate and
+// final bytes.
+type Sequence struct {
+	Kind       SequenceKind
+	Command    int
+	DCSCommand string
+	Data       []byte
+}
+
+type State struct {
+	vt *VTerm
+}
+
+func (st *State) CursorPos() Pos {
+	st.vt.mu.Lock()
+	defer st.vt.mu.Unlock()
+
+	c_state := st.obtain()
+	var c_pos C.VTermPos
+	C.vterm_state_get_cursorpos(c_state, &c_pos)
+	return newPosFromC(c_pos)
+}
+
+func (st *State) LineInfo(row int) (LineInfo, bool) {
+	st.vt.mu.Lock()
+	defer st.vt.mu.Unlock()
+
+	rows, _ := st.vt.size()
+	if row < 0 || rows <= row {
+		return LineInfo{}, false
+	}
+
+	c_state := st.obtain()
+	c_row, _ := go2cInt(row)
+	c_info := C.vterm_state_get_lineinfo(c_state, c_row)
+	if c_info == nil {
+		return LineInfo{}, false
+	}
+
+	info := LineInfo{
+		DoubleWidth:  C.cgo_vterm_lineinfo_doublewidth(c_info) != 0,
+		DoubleHeight: uint8(C.cgo_vterm_lineinfo_doubleheight(c_info)),
+		Continuation: C.cgo_vterm_lineinfo_continuation(c_info) != 0,
+	}
+	return info, true
+}
+
+func (st *State) DefaultColor() (Color, Color) {
+	st.vt.mu.Lock()
+	defer st.vt.mu.Unlock()
+
+	c_state := st.obtain()
+	var c_fg, c_bg C.VTermColor
+	C.vterm_state_get_default_colors(c_state, &c_fg, &c_bg)
+	return newColorFromC(c_fg), newColorFromC(c_bg)
+}
+
+func (st *State) PaletteColor(index byte) Color {
+	st.vt.mu.Lock()
+	defer st.vt.mu.Unlock()
+
+	c_state := st.obtain()
+	c_index := C.int(index)
+	var c_col C.VTermColor
+	C.vterm_state_get_palette_color(c_state, c_index, &c_col)
+	return newColorFromC(c_col)
+}
+
+// Modes reports the terminal modes set by the application. libvterm
+// keeps keypad, cursor key and bracketed paste modes private, so they are
+// detected from the sequences the keyboard functions would send. The
+// probe output is discarded and never reaches the pty.
+func (st *State) Modes() Modes {
+	st.vt.mu.Lock()
+	defer st.vt.mu.Unlock()
+
+	mouse, altscreen := st.vt.Screen().modes()
+	keypad := st.probe(func() {
+		C.vterm_keyboard_key(st.vt.vt, C.VTERM_KEY_KP_0, C.VTERM_MOD_NONE)
+	})
+	cursor := st.probe(func() {
+		C.vterm_keyboard_key(st.vt.vt, C.VTERM_KEY_UP, C.VTERM_MOD_NONE)
+	})
+	paste := st.probe(func() {
+		C.vterm_keyboard_start_paste(st.vt.vt)
+	})
+	_ = st.probe(func() {
+		C.vterm_keyboard_end_paste(st.vt.vt)
+	})
+
+	return Modes{
+		Mouse:          mouse,
+		AltScreen:      altscreen,
+		Keypad:         bytes.HasPrefix(keypad, []byte("\x1BO")),
+		CursorKeys:     bytes.HasPrefix(cursor, []byte("\x1BO")),
+		BracketedPaste: len(paste) > 0,
+	}
+}
+
+// probe must be called with the lock held. The output buffer is always
+// flushed before the lock is released, so anything in it after fn returns
+// was produced by fn.
+func (st *State) probe(fn func()) []byte {
+	fn()
+
+	buf, _ := st.vt.out.take()
+	return buf
+}
+
+// OSCAllowMax is the maximum number of OSC numbers kept by
+// SetSequenceFilter.
+const OSCAllowMax = C.CGO_VTERM_OSC_ALLOW_MAX
+
+// SetSequenceFilter selects which unrecognized sequences are queued:
+// OSC sequences whose number is in osc, and all DCS sequences if dcs is
+// true. At most OSCAllowMax OSC numbers are kept.
+func (st *State) SetSequenceFilter(osc []int, dcs bool) {
+	st.vt.mu.Lock()
+	defer st.vt.mu.Unlock()
+
+	if len(osc) > OSCAllowMax {
+		osc = osc[:OSCAllowMax]
+	}
+
+	c_user := st.fbdata()
+	for i, n := range osc {
+		c_user.osc_allow[i], _ = go2cInt(n)
+	}
+	c_user.osc_allow_len = C.int(len(osc))
+	c_user.dcs_allow = go2cBool(dcs)
+}
+
+// TakeSequences returns the queued sequences and clears the queue. Only
+// the most recent 32 sequences are kept, and data beyond 1024 bytes is
+// truncated.
+func (st *State) TakeSequences() []Sequence {
+	st.vt.mu.Lock()
+	defer st.vt.mu.Unlock()
+
+	c_user := st.fbdata()
+	n := int(c_user.seq_len)
+	seqs := make([]Sequence, n)
+	for i := 0; i < n; i++ {
+		c_seq := C.cgo_vterm_state_user_seq(c_user, C.int(i))
+		command, _ := c2goInt(c_seq.command)
+		seqs[i] = Sequence{
+			Kind:       SequenceKind(c_seq.kind),
+			Command:    command,
+			DCSCommand: C.GoStringN(&c_seq.dcs_command[0], C.int(c_seq.dcs_command_len)),
+			Data:       C.GoBytes(unsafe.Pointer(&c_seq.data[0]), C.int(c_seq.data_len)),
+		}
+	}
+	c_user.seq_head = 0
+	c_user.seq_len = 0
+	return seqs
+}
+
+func (st *State) fbdata() *C.CGoVTermStateUser {
+	c_state := st.obtain()
+	c_user := C.vterm_state_get_unrecognised_fbdata(c_state)
+	return (*C.CGoVTermStateUser)(c_user)
+}
+
+func (st *State) init() {
+	c_user := C.malloc(C.sizeof_CGoVTermStateUser)
+	_ = C.memset(c_user, 0, C.sizeof_CGoVTermStateUser)
+
+	c_state := st.obtain()
+	C.vterm_state_set_unrecognised_fallbacks(c_state, &C.cgo_vterm_state_user_fallbacks, c_user)
+}
+
+func (st *State) free() {
+	c_state := st.obtain()
+	c_user := C.vterm_state_get_unrecognised_fbdata(c_state)
+	C.vterm_state_set_unrecognised_fallbacks(c_state, nil, nil)
+	C.free(c_user)
+}
+
+func (st *State) obtain() *C.VTermState {
+	return C.vterm_obtain_state(st.vt.vt)
+}
@@ -0,0 +1,260 @@
+package vterm
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestStateCursorPos(t *testing.T) {
+	vt := New(30, 120)
+	_ = vt.Output().Close()
+	in := vt.Input()
+	st := vt.State()
+
+	want := Pos{Row: 0, Col: 0}
+	got := st.CursorPos()
+	if got != want {
+		t.Errorf("init: expected %#v, got %#v", want, got)
+	}
+
+	_, _ = in.Write([]byte("\x1B[5;10H"))
+	want = Pos{Row: 4, Col: 9}
+	got = st.CursorPos()
+	if got != want {
+		t.Errorf("move: expected %#v, got %#v", want, got)
+	}
+}
+
+func TestStateLineInfo(t *testing.T) {
+	vt := New(4, 10)
+	_ = vt.Output().Close()
+	vt.Screen().SetReflow(true)
+	in := vt.Input()
+	st := vt.State()
+	_, _ = in.Write([]byte("\x1B#6\r\n\x1B#3\r\n0123456789AB"))
+
+	tt := []struct {
+		name     string
+		inRow    int
+		wantInfo LineInfo
+		wantOK   bool
+	}{
+		{
+			name:     "DoubleWidth",
+			inRow:    0,
+			wantInfo: LineInfo{DoubleWidth: true},
+			wantOK:   true,
+		},
+		{
+			name:     "DoubleHeightTop",
+			inRow:    1,
+			wantInfo: LineInfo{DoubleWidth: true, DoubleHeight: 1},
+			wantOK:   true,
+		},
+		{
+			name:     "Normal",
+			inRow:    2,
+			wantInfo: LineInfo{},
+			wantOK:   true,
+		},
+		{
+			name:     "Continuation",
+			inRow:    3,
+			wantInfo: LineInfo{Continuation: true},
+			wantOK:   true,
+		},
+		{
+			name:     "Negative",
+			inRow:    -1,
+			wantInfo: LineInfo{},
+			wantOK:   false,
+		},
+		{
+			name:     "OutOfRange",
+			inRow:    4,
+			wantInfo: LineInfo{},
+			wantOK:   false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			gotInfo, gotOK := st.LineInfo(tc.inRow)
+			if gotInfo != tc.wantInfo {
+				t.Errorf("info: expected %#v, got %#v", tc.wantInfo, gotInfo)
+			}
+			if gotOK != tc.wantOK {
+				t.Errorf("ok: expected %t, got %t", tc.wantOK, gotOK)
+			}
+		})
+	}
+}
+
+func TestStateDefaultColor(t *testing.T) {
+	vt := New(30, 120)
+	_ = vt.Output().Close()
+	vt.Screen().SetDefaultColor(NewColorRGB(1, 2, 3), NewColorRGB(4, 5, 6))
+
+	gotFG, gotBG := vt.State().DefaultColor()
+	if !gotFG.IsRGB() || gotFG.Red != 1 || gotFG.Green != 2 || gotFG.Blue != 3 {
+		t.Errorf("fg: got %#v", gotFG)
+	}
+	if !gotBG.IsRGB() || gotBG.Red != 4 || gotBG.Green != 5 || gotBG.Blue != 6 {
+		t.Errorf("bg: got %#v", gotBG)
+	}
+}
+
+func TestStatePaletteColor(t *testing.T) {
+	vt := New(30, 120)
+	_ = vt.Output().Close()
+	vt.Screen().SetPaletteColor(3, NewColorRGB(7, 8, 9))
+
+	want := NewColorRGB(7, 8, 9)
+	got := vt.State().PaletteColor(3)
+	if !got.Equal(want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+}
+
+func TestStateModes(t *testing.T) {
+	tt := []struct {
+		name string
+		in   string
+		want Modes
+	}{
+		{
+			name: "Default",
+			in:   "",
+			want: Modes{},
+		},
+		{
+			name: "MouseClick",
+			in:   "\x1B[?1000h",
+			want: Modes{Mouse: MouseClick},
+		},
+		{
+			name: "MouseDrag",
+			in:   "\x1B[?1002h",
+			want: Modes{Mouse: MouseDrag},
+		},
+		{
+			name: "MouseMove",
+			in:   "\x1B[?1003h",
+			want: Modes{Mouse: MouseMove},
+		},
+		{
+			name: "MouseOff",
+			in:   "\x1B[?1000h\x1B[?1000l",
+			want: Modes{Mouse: MouseNone},
+		},
+		{
+			name: "AltScreen",
+			in:   "\x1B[?1049h",
+			want: Modes{AltScreen: true},
+		},
+		{
+			name: "Keypad",
+			in:   "\x1B=",
+			want: Modes{Keypad: true},
+		},
+		{
+			name: "CursorKeys",
+			in:   "\x1B[?1h",
+			want: Modes{CursorKeys: true},
+		},
+		{
+			name: "BracketedPaste",
+			in:   "\x1B[?2004h",
+			want: Modes{BracketedPaste: true},
+		},
+		{
+			name: "All",
+			in:   "\x1B[?1002h\x1B[?1049h\x1B=\x1B[?1h\x1B[?2004h",
+			want: Modes{
+				Mouse:          MouseDrag,
+				AltScreen:      true,
+				Keypad:         true,
+				CursorKeys:     true,
+				BracketedPaste: true,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			vt := New(30, 120)
+			_ = vt.Output().Close()
+			vt.Screen().SetAltScreen(true)
+			_, _ = vt.Input().Write([]byte(tc.in))
+
+			got := vt.State().Modes()
+			if got != tc.want {
+				t.Errorf("expected %#v, got %#v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStateModesNoOutput(t *testing.T) {
+	vt := New(30, 120)
+	out := vt.Output()
+	_, _ = vt.Input().Write([]byte("\x1B=\x1B[?2004h"))
+
+	_ = vt.State().Modes()
+	go func() {
+		vt.KeyboardRune('x', ModNone)
+		_ = out.Close()
+	}()
+
+	got, _ := io.ReadAll(out)
+	want := "x"
+	if string(got) != want {
+		t.Errorf("expected %#v, got %#v", want, string(got))
+	}
+}
+
+func TestStateTakeSequences(t *testing.T) {
+	vt := New(30, 120)
+	_ = vt.Output().Close()
+	in := vt.Input()
+	st := vt.State()
+
+	_, _ = in.Write([]byte("\x1B]7777;horn\x07"))
+	want := []Sequence{}
+	got := st.TakeSequences()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unfiltered: expected %#v, got %#v", want, got)
+	}
+
+	st.SetSequenceFilter([]int{7777}, true)
+	_, _ = in.Write([]byte("\x1B]7777;ho"))
+	_, _ = in.Write([]byte("rn\x1B\\"))
+	_, _ = in.Write([]byte("\x1B]7778;lights\x07"))
+	_, _ = in.Write([]byte("\x1BP+qabc\x1B\\"))
+	want = []Sequence{
+		{Kind: SequenceOSC, Command: 7777, DCSCommand: "", Data: []byte("horn")},
+		{Kind: SequenceDCS, Command: 0, DCSCommand: "+q", Data: []byte("abc")},
+	}
+	got = st.TakeSequences()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("filtered: expected %#v, got %#v", want, got)
+	}
+
+	want = []Sequence{}
+	got = st.TakeSequences()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cleared: expected %#v, got %#v", want, got)
+	}
+
+	for i := 0; i < 40; i++ {
+		_, _ = in.Write([]byte(fmt.Sprintf("\x1B]7777;%d\x07", i)))
+	}
+	got = st.TakeSequences()
+	if len(got) != 32 || string(got[0].Data) != "8" || string(got[31].Data) != "39" {
+		t.Errorf("overflow: got %d sequences", len(got))
+	}
+}
@@ -1,6 +1,6 @@
 package vterm
 
-// #cgo pkg-config: vterm
+// #cgo CFLAGS: -I${SRCDIR}/libvterm/include
 // #include <vterm.h>
 import "C"
 import (
@@ -32,7 +32,9 @@ func New(rows, cols int) *VTerm {
 	}
 	runtime.SetFinalizer(vt, (*VTerm).free)
 
+	vt.out.init(c_vt)
 	vt.Screen().init()
+	vt.State().init()
 	return vt
 }
 
@@ -48,6 +50,10 @@ func (vt *VTerm) Screen() *Screen {
 	return &Screen{vt: vt}
 }
 
+func (vt *VTerm) State() *State {
+	return &State{vt: vt}
+}
+
 func (vt *VTerm) GetSize() (int, int) {
 	vt.mu.Lock()
 	defer vt.mu.Unlock()
@@ -96,12 +102,14 @@ func (vt *VTerm) size() (int, int) {
 	return rows, cols
 }
 
-func (vt *VTerm) flush() {
-	vt.out.flush(vt.vt)
+func (vt *VTerm) flush() error {
+	return vt.out.flush()
 }
 
 func (vt *VTerm) free() {
+	vt.State().free()
 	vt.Screen().free()
+	vt.out.free(vt.vt)
 
 	C.vterm_free(vt.vt)
 	vt.vt = nil
@@ -124,3 +132,22 @@ func (pos Pos) toC() C.VTermPos {
 	col, _ := go2cInt(pos.Col)
 	return C.VTermPos{row: row, col: col}
 }
+
+// Rect is a screen region. The end row and column are exclusive.
+type Rect struct {
+	StartRow, EndRow int
+	StartCol, EndCol int
+}
+
+func newRectFromC(rect C.VTermRect) Rect {
+	startRow, _ := c2goInt(rect.start_row)
+	endRow, _ := c2goInt(rect.end_row)
+	startCol, _ := c2goInt(rect.start_col)
+	endCol, _ := c2goInt(rect.end_col)
+	return Rect{
+		StartRow: startRow,
+		EndRow:   endRow,
+		StartCol: startCol,
+		EndCol:   endCol,
+	}
+}
@@ -18,14 +18,20 @@ type MockTerminal struct {
 	ErrOpen          error
 	ErrSession       error
 	ErrStartProcess  error
+	ErrForeground    error
+	ErrSignal        error
 	ErrGetSize       error
 	ErrSetSize       error
 	ErrCloseSession  error
 	ErrCloseTerminal error
+	ErrWrite         error
 	PID              int
 
 	Size         Size
 	Cmd          Cmd
+	Foreground   Process
+	Signal       Signal
+	SignalTarget SignalTarget
 	OpenTerminal bool
 	OpenSession  bool
 
@@ -59,6 +65,9 @@ func (t *MockTerminal) Read(p []byte) (int, error) {
 }
 
 func (t *MockTerminal) Write(p []byte) (int, error) {
+	if t.ErrWrite != nil {
+		return 0, t.ErrWrite
+	}
 	return t.ob.Write(p)
 }
 
@@ -120,6 +129,32 @@ func (s *MockSession) StartProcess(cmd Cmd) (*os.Process, error) {
 	return proc, nil
 }
 
+func (s *MockSession) ForegroundProcess() (Process, error) {
+	if s.T.ErrForeground != nil {
+		return Process{}, s.T.ErrForeground
+	}
+
+	if !s.T.OpenSession {
+		panic(ErrMockSessionNotOpen)
+	}
+
+	return s.T.Foreground, nil
+}
+
+func (s *MockSession) Signal(sig Signal, target SignalTarget) error {
+	if s.T.ErrSignal != nil {
+		return s.T.ErrSignal
+	}
+
+	if !s.T.OpenSession {
+		panic(ErrMockSessionNotOpen)
+	}
+
+	s.T.Signal = sig
+	s.T.SignalTarget = target
+	return nil
+}
+
 func (s *MockSession) GetSize() (Size, error) {
 	if s.T.ErrGetSize != nil {
 		return Size{}, s.T.ErrGetSize
@@ -436,6 +436,163 @@ func TestMockSessionStartProcess(t *testing.T) {
 	}
 }
 
+func TestMockSessionForegroundProcess(t *testing.T) {
+	errDummy := errors.New("dummy error")
+
+	tt := []struct {
+		name        string
+		t           *MockTerminal
+		wantRecover any
+		wantProc    Process
+		wantErr     error
+	}{
+		{
+			name: "ErrForeground",
+			t: &MockTerminal{
+				ErrForeground: errDummy,
+				Foreground:    Process{PID: 1234, Name: "vim"},
+				OpenTerminal:  true,
+				OpenSession:   true,
+			},
+			wantRecover: nil,
+			wantProc:    Process{},
+			wantErr:     errDummy,
+		},
+		{
+			name: "SessionNotOpen",
+			t: &MockTerminal{
+				ErrForeground: nil,
+				Foreground:    Process{PID: 1234, Name: "vim"},
+				OpenTerminal:  true,
+				OpenSession:   false,
+			},
+			wantRecover: ErrMockSessionNotOpen,
+		},
+		{
+			name: "Normal",
+			t: &MockTerminal{
+				ErrForeground: nil,
+				Foreground:    Process{PID: 1234, Name: "vim"},
+				OpenTerminal:  true,
+				OpenSession:   true,
+			},
+			wantRecover: nil,
+			wantProc:    Process{PID: 1234, Name: "vim"},
+			wantErr:     nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var gotRecover any
+			func() {
+				defer func() {
+					gotRecover = recover()
+				}()
+
+				s := &MockSession{T: tc.t}
+				gotProc, gotErr := s.ForegroundProcess()
+
+				if gotProc != tc.wantProc {
+					t.Errorf("proc: expected %#v, got %#v", tc.wantProc, gotProc)
+				}
+				if gotErr != tc.wantErr {
+					t.Errorf("err: expected %#v, got %#v", tc.wantErr, gotErr)
+				}
+			}()
+
+			if gotRecover != tc.wantRecover {
+				panic(gotRecover)
+			}
+		})
+	}
+}
+
+func TestMockSessionSignal(t *testing.T) {
+	errDummy := errors.New("dummy error")
+
+	tt := []struct {
+		name             string
+		t                *MockTerminal
+		inSig            Signal
+		inTarget         SignalTarget
+		wantRecover      any
+		wantSignal       Signal
+		wantSignalTarget SignalTarget
+		wantErr          error
+	}{
+		{
+			name: "ErrSignal",
+			t: &MockTerminal{
+				ErrSignal:    errDummy,
+				OpenTerminal: true,
+				OpenSession:  true,
+			},
+			inSig:            SignalINT,
+			inTarget:         TargetSession,
+			wantRecover:      nil,
+			wantSignal:       0,
+			wantSignalTarget: 0,
+			wantErr:          errDummy,
+		},
+		{
+			name: "SessionNotOpen",
+			t: &MockTerminal{
+				ErrSignal:    nil,
+				OpenTerminal: true,
+				OpenSession:  false,
+			},
+			inSig:       SignalINT,
+			inTarget:    TargetSession,
+			wantRecover: ErrMockSessionNotOpen,
+		},
+		{
+			name: "Normal",
+			t: &MockTerminal{
+				ErrSignal:    nil,
+				OpenTerminal: true,
+				OpenSession:  true,
+			},
+			inSig:            SignalINT,
+			inTarget:         TargetSession,
+			wantRecover:      nil,
+			wantSignal:       SignalINT,
+			wantSignalTarget: TargetSession,
+			wantErr:          nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var gotRecover any
+			func() {
+				defer func() {
+					gotRecover = recover()
+				}()
+
+				s := &MockSession{T: tc.t}
+				gotErr := s.Signal(tc.inSig, tc.inTarget)
+
+				if gotErr != tc.wantErr {
+					t.Errorf("err: expected %#v, got %#v", tc.wantErr, gotErr)
+				}
+				if tc.t.Signal != tc.wantSignal {
+					t.Errorf("signal: expected %#v, got %#v", tc.wantSignal, tc.t.Signal)
+				}
+				if tc.t.SignalTarget != tc.wantSignalTarget {
+					t.Errorf("signal target: expected %#v, got %#v", tc.wantSignalTarget, tc.t.SignalTarget)
+				}
+			}()
+
+			if gotRecover != tc.wantRecover {
+				panic(gotRecover)
+			}
+		})
+	}
+}
+
 func TestMockSessionGetSize(t *testing.T) {
 	errDummy := errors.New("dummy error")
 
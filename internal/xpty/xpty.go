@@ -7,7 +7,17 @@ import (
 	"os"
 )
 
-var ErrUnsupported = errors.New("platform not supported by xpty")
+var (
+	ErrUnsupported  = errors.New("platform not supported by xpty")
+	ErrNoForeground = errors.New("no foreground process on terminal")
+	ErrNoProcess    = errors.New("no process started in session")
+
+	// ErrSignalByInput is returned by Session.Signal when the signal can
+	// only be raised by typing its control character into the terminal.
+	// The caller should send it along with the other keyboard input, so
+	// that it does not land in the middle of an escape sequence.
+	ErrSignalByInput = errors.New("signal must be sent as terminal input")
+)
 
 func Open() (Terminal, error) {
 	return open()
@@ -20,6 +30,8 @@ type Terminal interface {
 
 type Session interface {
 	StartProcess(cmd Cmd) (*os.Process, error)
+	ForegroundProcess() (Process, error)
+	Signal(sig Signal, target SignalTarget) error
 	GetSize() (Size, error)
 	SetSize(Size) error
 	Close() error
@@ -32,6 +44,43 @@ type Size struct {
 type Cmd struct {
 	Path string
 	Args []string
+
+	// Env is the environment of the process in "key=value" form. If Env is
+	// nil, the process inherits the environment of the caller.
+	Env []string
+
+	// Dir is the working directory of the process. If Dir is empty, the
+	// process runs in the current directory of the caller.
+	Dir string
+}
+
+type Process struct {
+	PID  int
+	Name string
+}
+
+type Signal int
+
+const (
+	SignalHUP Signal = iota + 1
+	SignalINT
+	SignalKILL
+	SignalTERM
+	SignalTSTP
+)
+
+type SignalTarget int
+
+const (
+	TargetForeground SignalTarget = iota
+	TargetSession
+)
+
+// DefaultSignalTarget returns the target used for sig when the caller does
+// not choose one: the foreground process group, unless the platform can
+// only deliver sig to the whole session.
+func DefaultSignalTarget(sig Signal) SignalTarget {
+	return defaultSignalTarget(sig)
 }
 
 type SizeError struct {
@@ -41,3 +90,12 @@ type SizeError struct {
 func (e *SizeError) Error() string {
 	return fmt.Sprintf("attempt to set invalid terminal winsize (%d, %d)", e.Size.Row, e.Size.Col)
 }
+
+// CheckSize reports whether size can be set on a terminal of this
+// platform.
+func CheckSize(size Size) error {
+	if size.Row <= 0 || maxSize < size.Row || size.Col <= 0 || maxSize < size.Col {
+		return &SizeError{Size: size}
+	}
+	return nil
+}
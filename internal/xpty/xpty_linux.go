@@ -3,6 +3,7 @@
 package xpty
 
 import (
+	"bytes"
 	"fmt"
 	"math"
 	"os"
@@ -80,11 +81,14 @@ func (t *terminal) Session(size Size) (Session, error) {
 }
 
 type session struct {
-	t *terminal
+	t   *terminal
+	pid int
 }
 
 func (s *session) StartProcess(cmd Cmd) (*os.Process, error) {
-	return os.StartProcess(cmd.Path, cmd.Args[:], &os.ProcAttr{
+	proc, err := os.StartProcess(cmd.Path, cmd.Args[:], &os.ProcAttr{
+		Dir:   cmd.Dir,
+		Env:   cmd.Env,
 		Files: []*os.File{s.t.pts, s.t.pts, s.t.pts},
 		Sys: &syscall.SysProcAttr{
 			Setsid:  true,
@@ -92,6 +96,72 @@ func (s *session) StartProcess(cmd Cmd) (*os.Process, error) {
 			Ctty:    0,
 		},
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The process is started with setsid(2), so its PID is also the
+	// session ID.
+	s.pid = proc.Pid
+	return proc, nil
+}
+
+func (s *session) ForegroundProcess() (Process, error) {
+	pgrp, err := s.foreground()
+	if err != nil {
+		return Process{}, err
+	}
+
+	// The process group leader may already have exited while other members
+	// of the group are still running, so the name is best-effort.
+	proc := Process{PID: pgrp}
+	comm, err := os.ReadFile("/proc/" + strconv.Itoa(pgrp) + "/comm")
+	if err == nil {
+		proc.Name = string(bytes.TrimSuffix(comm, []byte("\n")))
+	}
+	return proc, nil
+}
+
+func defaultSignalTarget(sig Signal) SignalTarget {
+	return TargetForeground
+}
+
+func (s *session) Signal(sig Signal, target SignalTarget) error {
+	usig, ok := castSignal(sig)
+	if !ok {
+		return fmt.Errorf("invalid signal %d", sig)
+	}
+
+	switch target {
+	case TargetForeground:
+		pgrp, err := s.foreground()
+		if err != nil {
+			return err
+		}
+		return unix.Kill(-pgrp, usig)
+
+	case TargetSession:
+		if s.pid <= 0 {
+			return ErrNoProcess
+		}
+		pids, err := sessionMembers(s.pid)
+		if err != nil {
+			return err
+		}
+		if len(pids) <= 0 {
+			return ErrNoProcess
+		}
+		for _, pid := range pids {
+			err := unix.Kill(pid, usig)
+			if err != nil && err != unix.ESRCH {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("invalid signal target %d", target)
+	}
 }
 
 func (s *session) GetSize() (Size, error) {
@@ -147,6 +217,86 @@ func (s *session) Close() error {
 	return nil
 }
 
+func (s *session) foreground() (int, error) {
+	raw, errRaw := s.t.ptm.SyscallConn()
+	if errRaw != nil {
+		return 0, errRaw
+	}
+
+	var pgrp int
+	var errIoctl error
+	errCtrl := raw.Control(func(fd uintptr) {
+		pgrp, errIoctl = unix.IoctlGetInt(int(fd), unix.TIOCGPGRP)
+	})
+	if errCtrl != nil {
+		return 0, errCtrl
+	}
+	if errIoctl != nil {
+		return 0, fmt.Errorf("ioctl TIOCGPGRP: %w", errIoctl)
+	}
+	if pgrp <= 0 {
+		return 0, ErrNoForeground
+	}
+	return pgrp, nil
+}
+
+func sessionMembers(sid int) ([]int, error) {
+	ents, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, ent := range ents {
+		pid, err := strconv.Atoi(ent.Name())
+		if err != nil {
+			continue
+		}
+
+		// Processes may exit while we are scanning, so unreadable entries
+		// are skipped instead of being reported as errors.
+		stat, err := os.ReadFile("/proc/" + ent.Name() + "/stat")
+		if err != nil {
+			continue
+		}
+
+		// The command name in the second field may contain spaces and
+		// parentheses, so the fields are counted from the last ')'.
+		idx := bytes.LastIndexByte(stat, ')')
+		if idx < 0 {
+			continue
+		}
+		fields := bytes.Fields(stat[idx+1:])
+		if len(fields) < 4 {
+			continue
+		}
+		s, err := strconv.Atoi(string(fields[3]))
+		if err != nil || s != sid {
+			continue
+		}
+
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+func castSignal(sig Signal) (unix.Signal, bool) {
+	switch sig {
+	case SignalHUP:
+		return unix.SIGHUP, true
+	case SignalINT:
+		return unix.SIGINT, true
+	case SignalKILL:
+		return unix.SIGKILL, true
+	case SignalTERM:
+		return unix.SIGTERM, true
+	case SignalTSTP:
+		return unix.SIGTSTP, true
+	default:
+		return 0, false
+	}
+}
+
 func ptsname(f *os.File) (string, error) {
 	raw, errRaw := f.SyscallConn()
 	if errRaw != nil {
@@ -188,9 +338,11 @@ func unlockpt(f *os.File) error {
 	return nil
 }
 
+const maxSize = math.MaxUint16
+
 func castWinsize(size Size) (unix.Winsize, error) {
-	if size.Row <= 0 || math.MaxUint16 < size.Row || size.Col <= 0 || math.MaxUint16 < size.Col {
-		return unix.Winsize{}, &SizeError{Size: size}
+	if err := CheckSize(size); err != nil {
+		return unix.Winsize{}, err
 	}
 
 	ws := unix.Winsize{Row: uint16(size.Row), Col: uint16(size.Col)}
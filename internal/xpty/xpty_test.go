@@ -0,0 +1,69 @@
+package xpty
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestCheckSize(t *testing.T) {
+	tt := []struct {
+		name   string
+		inSize Size
+		wantOK bool
+	}{
+		{name: "Normal", inSize: Size{Row: 27, Col: 58}, wantOK: true},
+		{name: "Min", inSize: Size{Row: 1, Col: 1}, wantOK: true},
+		{name: "Max", inSize: Size{Row: maxSize, Col: maxSize}, wantOK: true},
+		{name: "ZeroRow", inSize: Size{Row: 0, Col: 58}, wantOK: false},
+		{name: "ZeroCol", inSize: Size{Row: 27, Col: 0}, wantOK: false},
+		{name: "NegativeRow", inSize: Size{Row: -1, Col: 58}, wantOK: false},
+		{name: "LargeRow", inSize: Size{Row: maxSize + 1, Col: 58}, wantOK: false},
+		{name: "LargeCol", inSize: Size{Row: 27, Col: maxSize + 1}, wantOK: false},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckSize(tc.inSize)
+			if tc.wantOK && err != nil {
+				t.Errorf("expected nil, got %#v", err)
+			}
+
+			var errSize *SizeError
+			if !tc.wantOK && (!errors.As(err, &errSize) || errSize.Size != tc.inSize) {
+				t.Errorf("expected SizeError, got %#v", err)
+			}
+		})
+	}
+}
+
+func TestDefaultSignalTarget(t *testing.T) {
+	tt := []struct {
+		name        string
+		inSig       Signal
+		want        SignalTarget
+		wantWindows SignalTarget
+	}{
+		{name: "HUP", inSig: SignalHUP, want: TargetForeground, wantWindows: TargetForeground},
+		{name: "INT", inSig: SignalINT, want: TargetForeground, wantWindows: TargetForeground},
+		{name: "KILL", inSig: SignalKILL, want: TargetForeground, wantWindows: TargetSession},
+		{name: "TERM", inSig: SignalTERM, want: TargetForeground, wantWindows: TargetSession},
+		{name: "TSTP", inSig: SignalTSTP, want: TargetForeground, wantWindows: TargetForeground},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			want := tc.want
+			if runtime.GOOS == "windows" {
+				want = tc.wantWindows
+			}
+
+			got := DefaultSignalTarget(tc.inSig)
+			if got != want {
+				t.Errorf("expected %#v, got %#v", want, got)
+			}
+		})
+	}
+}
@@ -2,6 +2,14 @@
 
 package xpty
 
+import "math"
+
+const maxSize = math.MaxUint16
+
 func open() (Terminal, error) {
 	return nil, ErrUnsupported
 }
+
+func defaultSignalTarget(sig Signal) SignalTarget {
+	return TargetForeground
+}
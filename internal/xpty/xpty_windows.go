@@ -129,10 +129,11 @@ func (t *terminal) Session(size Size) (Session, error) {
 }
 
 type session struct {
-	mu sync.RWMutex
-	ok bool
-	pc windows.Handle
-	sz Size
+	mu  sync.RWMutex
+	ok  bool
+	pc  windows.Handle
+	sz  Size
+	pid int
 }
 
 func (s *session) StartProcess(cmd Cmd) (*os.Process, error) {
@@ -149,14 +150,30 @@ func (s *session) StartProcess(cmd Cmd) (*os.Process, error) {
 		return nil, fmt.Errorf("encode command arguments to UTF-16: %w", err)
 	}
 
+	var envw *uint16
+	if cmd.Env != nil {
+		envw, err = createEnvBlock(cmd.Env)
+		if err != nil {
+			return nil, fmt.Errorf("encode environment to UTF-16: %w", err)
+		}
+	}
+
+	var dirw *uint16
+	if cmd.Dir != "" {
+		dirw, err = windows.UTF16PtrFromString(cmd.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("encode working directory to UTF-16: %w", err)
+		}
+	}
+
 	al, err := windows.NewProcThreadAttributeList(1)
 	if err != nil {
 		return nil, fmt.Errorf("NewProcThreadAttributeList: %w", err)
 	}
 	defer al.Delete()
 
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	if !s.ok {
 		return nil, os.ErrClosed
@@ -173,7 +190,7 @@ func (s *session) StartProcess(cmd Cmd) (*os.Process, error) {
 
 	pi := &windows.ProcessInformation{}
 	flags := uint32(windows.CREATE_DEFAULT_ERROR_MODE | windows.CREATE_UNICODE_ENVIRONMENT | windows.EXTENDED_STARTUPINFO_PRESENT)
-	err = windows.CreateProcess(pathw, argsw, nil, nil, false, flags, nil, nil, &si.StartupInfo, pi)
+	err = windows.CreateProcess(pathw, argsw, nil, nil, false, flags, envw, dirw, &si.StartupInfo, pi)
 	if err != nil {
 		return nil, fmt.Errorf("CreateProcess: %w", err)
 	}
@@ -190,9 +207,46 @@ func (s *session) StartProcess(cmd Cmd) (*os.Process, error) {
 		}
 	}()
 
+	s.pid = int(pi.ProcessId)
 	return os.FindProcess(int(pi.ProcessId))
 }
 
+func (s *session) ForegroundProcess() (Process, error) {
+	// ConPTY does not expose the foreground process of the pseudo console.
+	return Process{}, ErrUnsupported
+}
+
+func defaultSignalTarget(sig Signal) SignalTarget {
+	// KILL and TERM can only end the whole session; see session.Signal.
+	if sig == SignalKILL || sig == SignalTERM {
+		return TargetSession
+	}
+	return TargetForeground
+}
+
+func (s *session) Signal(sig Signal, target SignalTarget) error {
+	switch sig {
+	case SignalINT:
+		// ConPTY raises CTRL_C_EVENT for the attached processes when it
+		// reads ETX from its input, which only reaches the processes
+		// reading the console, not the whole session.
+		if target == TargetSession {
+			return ErrUnsupported
+		}
+		return ErrSignalByInput
+
+	case SignalKILL, SignalTERM:
+		// There is no notion of a foreground process group on Windows.
+		if target != TargetSession {
+			return ErrUnsupported
+		}
+		return s.terminate()
+
+	default:
+		return ErrUnsupported
+	}
+}
+
 func (s *session) GetSize() (Size, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -237,6 +291,35 @@ func (s *session) Close() error {
 	return closePseudoConsole(s.pc)
 }
 
+func (s *session) terminate() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.ok {
+		return os.ErrClosed
+	}
+	if s.pid <= 0 {
+		return ErrNoProcess
+	}
+
+	h, err := windows.OpenProcess(windows.PROCESS_TERMINATE, false, uint32(s.pid))
+	if err != nil {
+		return fmt.Errorf("OpenProcess: %w", err)
+	}
+	defer func() {
+		err := windows.CloseHandle(h)
+		if err != nil {
+			panic(err)
+		}
+	}()
+
+	err = windows.TerminateProcess(h, 1)
+	if err != nil {
+		return fmt.Errorf("TerminateProcess: %w", err)
+	}
+	return nil
+}
+
 var (
 	dllKernel32             = windows.NewLazySystemDLL("kernel32.dll")
 	procCreatePseudoConsole = dllKernel32.NewProc("CreatePseudoConsole")
@@ -305,9 +388,29 @@ func (h hresult) Error() string {
 	return fmt.Sprintf("HRESULT(0x%08x)", uint32(h.n))
 }
 
+// createEnvBlock builds a Unicode environment block for CreateProcess: a
+// sequence of NUL-terminated "key=value" strings followed by another NUL.
+func createEnvBlock(env []string) (*uint16, error) {
+	block := make([]uint16, 0)
+	for _, kv := range env {
+		s, err := windows.UTF16FromString(kv)
+		if err != nil {
+			return nil, err
+		}
+		block = append(block, s...)
+	}
+	if len(block) <= 0 {
+		block = append(block, 0)
+	}
+	block = append(block, 0)
+	return &block[0], nil
+}
+
+const maxSize = math.MaxInt16
+
 func castWindowsCoord(size Size) (windows.Coord, error) {
-	if size.Row <= 0 || math.MaxInt16 < size.Row || size.Col <= 0 || math.MaxInt16 < size.Col {
-		return windows.Coord{}, &SizeError{Size: size}
+	if err := CheckSize(size); err != nil {
+		return windows.Coord{}, err
 	}
 
 	wsz := windows.Coord{X: int16(size.Col), Y: int16(size.Row)}
@@ -108,6 +108,20 @@ func parent() {
 		panic(fmt.Errorf("%d, %d", size.Row, size.Col))
 	}
 
+	fg, err := sess.ForegroundProcess()
+	if err != nil && !errors.Is(err, xpty.ErrUnsupported) {
+		_ = sess.Close()
+		_ = pty.Close()
+		<-errCopy
+		panic(err)
+	}
+	if err == nil && fg.PID != proc.Pid {
+		_ = sess.Close()
+		_ = pty.Close()
+		<-errCopy
+		panic(fmt.Errorf("foreground %d, expected %d", fg.PID, proc.Pid))
+	}
+
 	err = sess.SetSize(xpty.Size{Row: 40, Col: 80})
 	if err != nil {
 		_ = sess.Close()
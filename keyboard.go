@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"unicode/utf8"
 
 	"github.com/gcrtnst/sw-term-server/internal/vterm"
@@ -55,6 +56,106 @@ var vtermKeyMap = map[Key]vterm.Key{
 	"KP=":        vterm.KeyKPEqual,
 }
 
+// libvterm does not encode function keys beyond F12, so F13-F24 are sent
+// as shifted F1-F12 like xterm does.
+var shiftFunctionKeyMap = map[Key]vterm.Key{
+	"F13": vterm.KeyFunction1,
+	"F14": vterm.KeyFunction2,
+	"F15": vterm.KeyFunction3,
+	"F16": vterm.KeyFunction4,
+	"F17": vterm.KeyFunction5,
+	"F18": vterm.KeyFunction6,
+	"F19": vterm.KeyFunction7,
+	"F20": vterm.KeyFunction8,
+	"F21": vterm.KeyFunction9,
+	"F22": vterm.KeyFunction10,
+	"F23": vterm.KeyFunction11,
+	"F24": vterm.KeyFunction12,
+}
+
+var runeKeyMap = map[Key]rune{
+	"Space": ' ',
+}
+
+var keyModPrefixes = []struct {
+	prefix string
+	mod    vterm.Modifier
+}{
+	{prefix: "C-", mod: vterm.ModCtrl},
+	{prefix: "M-", mod: vterm.ModAlt},
+	{prefix: "S-", mod: vterm.ModShift},
+	{prefix: "Ctrl-", mod: vterm.ModCtrl},
+	{prefix: "Ctrl+", mod: vterm.ModCtrl},
+	{prefix: "Alt-", mod: vterm.ModAlt},
+	{prefix: "Alt+", mod: vterm.ModAlt},
+	{prefix: "Shift-", mod: vterm.ModShift},
+	{prefix: "Shift+", mod: vterm.ModShift},
+}
+
+// KeyStroke is a key resolved for libvterm. Key is KeyNone when the
+// stroke is the rune Rune.
+type KeyStroke struct {
+	Key  vterm.Key
+	Rune rune
+	Mod  vterm.Modifier
+}
+
+// Parse resolves k to a key stroke. The name may start with modifier
+// prefixes such as "C-", "M-" and "S-" (or "Ctrl-", "Alt+", "Shift+"),
+// each used at most once. A name matching a key or a single rune as a
+// whole is never split, so "-" and "KP-" keep their meaning.
+func (k Key) Parse() (KeyStroke, bool) {
+	var mod vterm.Modifier
+	for {
+		if ks, ok := k.stroke(); ok {
+			ks.Mod |= mod
+			// Ctrl-@ is NUL, which libvterm sends for Ctrl-Space.
+			if ks.Key == vterm.KeyNone && ks.Rune == '@' && ks.Mod&vterm.ModCtrl != 0 {
+				ks.Rune = ' '
+			}
+			return ks, true
+		}
+
+		found := false
+		for _, p := range keyModPrefixes {
+			if strings.HasPrefix(string(k), p.prefix) {
+				if mod&p.mod != 0 {
+					return KeyStroke{}, false
+				}
+				mod |= p.mod
+				k = k[len(p.prefix):]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return KeyStroke{}, false
+		}
+	}
+}
+
+func (k Key) stroke() (KeyStroke, bool) {
+	if vk, ok := k.VTermKey(); ok {
+		return KeyStroke{Key: vk}, true
+	}
+	if vk, ok := shiftFunctionKeyMap[k]; ok {
+		return KeyStroke{Key: vk, Mod: vterm.ModShift}, true
+	}
+	if r, ok := runeKeyMap[k]; ok {
+		return KeyStroke{Rune: r}, true
+	}
+	if r, ok := k.Rune(); ok {
+		return KeyStroke{Rune: r}, true
+	}
+	return KeyStroke{}, false
+}
+
+// Valid reports whether k can be parsed as a key stroke.
+func (k Key) Valid() bool {
+	_, ok := k.Parse()
+	return ok
+}
+
 func (k Key) Rune() (rune, bool) {
 	r, size := utf8.DecodeRuneInString(string(k))
 	if (r == utf8.RuneError && (size == 0 || size == 1)) || (size != len(k)) {
@@ -99,3 +99,615 @@ func TestKeyVTermKey(t *testing.T) {
 		})
 	}
 }
+
+func TestKeyValid(t *testing.T) {
+	tt := []struct {
+		name string
+		inK  Key
+		want bool
+	}{
+		{name: "Key", inK: "Enter", want: true},
+		{name: "Rune", inK: "A", want: true},
+		{name: "Empty", inK: "", want: false},
+		{name: "Unknown", inK: "Nonexistent", want: false},
+		{name: "Modifier", inK: "C-a", want: true},
+		{name: "DuplicateModifier", inK: "C-C-a", want: false},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.inK.Valid()
+			if got != tc.want {
+				t.Errorf("expected %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestKeyParse(t *testing.T) {
+	tt := []struct {
+		name   string
+		inK    Key
+		wantKS KeyStroke
+		wantOK bool
+	}{
+		{
+			name:   "Enter",
+			inK:    "Enter",
+			wantKS: KeyStroke{Key: vterm.KeyEnter},
+			wantOK: true,
+		},
+		{
+			name:   "Tab",
+			inK:    "Tab",
+			wantKS: KeyStroke{Key: vterm.KeyTab},
+			wantOK: true,
+		},
+		{
+			name:   "Backspace",
+			inK:    "Backspace",
+			wantKS: KeyStroke{Key: vterm.KeyBackspace},
+			wantOK: true,
+		},
+		{
+			name:   "Escape",
+			inK:    "Escape",
+			wantKS: KeyStroke{Key: vterm.KeyEscape},
+			wantOK: true,
+		},
+		{
+			name:   "ArrowUp",
+			inK:    "ArrowUp",
+			wantKS: KeyStroke{Key: vterm.KeyUp},
+			wantOK: true,
+		},
+		{
+			name:   "ArrowDown",
+			inK:    "ArrowDown",
+			wantKS: KeyStroke{Key: vterm.KeyDown},
+			wantOK: true,
+		},
+		{
+			name:   "ArrowLeft",
+			inK:    "ArrowLeft",
+			wantKS: KeyStroke{Key: vterm.KeyLeft},
+			wantOK: true,
+		},
+		{
+			name:   "ArrowRight",
+			inK:    "ArrowRight",
+			wantKS: KeyStroke{Key: vterm.KeyRight},
+			wantOK: true,
+		},
+		{
+			name:   "Insert",
+			inK:    "Insert",
+			wantKS: KeyStroke{Key: vterm.KeyIns},
+			wantOK: true,
+		},
+		{
+			name:   "Delete",
+			inK:    "Delete",
+			wantKS: KeyStroke{Key: vterm.KeyDel},
+			wantOK: true,
+		},
+		{
+			name:   "Home",
+			inK:    "Home",
+			wantKS: KeyStroke{Key: vterm.KeyHome},
+			wantOK: true,
+		},
+		{
+			name:   "End",
+			inK:    "End",
+			wantKS: KeyStroke{Key: vterm.KeyEnd},
+			wantOK: true,
+		},
+		{
+			name:   "PageUp",
+			inK:    "PageUp",
+			wantKS: KeyStroke{Key: vterm.KeyPageup},
+			wantOK: true,
+		},
+		{
+			name:   "PageDown",
+			inK:    "PageDown",
+			wantKS: KeyStroke{Key: vterm.KeyPagedown},
+			wantOK: true,
+		},
+		{
+			name:   "F1",
+			inK:    "F1",
+			wantKS: KeyStroke{Key: vterm.KeyFunction1},
+			wantOK: true,
+		},
+		{
+			name:   "F2",
+			inK:    "F2",
+			wantKS: KeyStroke{Key: vterm.KeyFunction2},
+			wantOK: true,
+		},
+		{
+			name:   "F3",
+			inK:    "F3",
+			wantKS: KeyStroke{Key: vterm.KeyFunction3},
+			wantOK: true,
+		},
+		{
+			name:   "F4",
+			inK:    "F4",
+			wantKS: KeyStroke{Key: vterm.KeyFunction4},
+			wantOK: true,
+		},
+		{
+			name:   "F5",
+			inK:    "F5",
+			wantKS: KeyStroke{Key: vterm.KeyFunction5},
+			wantOK: true,
+		},
+		{
+			name:   "F6",
+			inK:    "F6",
+			wantKS: KeyStroke{Key: vterm.KeyFunction6},
+			wantOK: true,
+		},
+		{
+			name:   "F7",
+			inK:    "F7",
+			wantKS: KeyStroke{Key: vterm.KeyFunction7},
+			wantOK: true,
+		},
+		{
+			name:   "F8",
+			inK:    "F8",
+			wantKS: KeyStroke{Key: vterm.KeyFunction8},
+			wantOK: true,
+		},
+		{
+			name:   "F9",
+			inK:    "F9",
+			wantKS: KeyStroke{Key: vterm.KeyFunction9},
+			wantOK: true,
+		},
+		{
+			name:   "F10",
+			inK:    "F10",
+			wantKS: KeyStroke{Key: vterm.KeyFunction10},
+			wantOK: true,
+		},
+		{
+			name:   "F11",
+			inK:    "F11",
+			wantKS: KeyStroke{Key: vterm.KeyFunction11},
+			wantOK: true,
+		},
+		{
+			name:   "F12",
+			inK:    "F12",
+			wantKS: KeyStroke{Key: vterm.KeyFunction12},
+			wantOK: true,
+		},
+		{
+			name:   "KP0",
+			inK:    "KP0",
+			wantKS: KeyStroke{Key: vterm.KeyKP0},
+			wantOK: true,
+		},
+		{
+			name:   "KP1",
+			inK:    "KP1",
+			wantKS: KeyStroke{Key: vterm.KeyKP1},
+			wantOK: true,
+		},
+		{
+			name:   "KP2",
+			inK:    "KP2",
+			wantKS: KeyStroke{Key: vterm.KeyKP2},
+			wantOK: true,
+		},
+		{
+			name:   "KP3",
+			inK:    "KP3",
+			wantKS: KeyStroke{Key: vterm.KeyKP3},
+			wantOK: true,
+		},
+		{
+			name:   "KP4",
+			inK:    "KP4",
+			wantKS: KeyStroke{Key: vterm.KeyKP4},
+			wantOK: true,
+		},
+		{
+			name:   "KP5",
+			inK:    "KP5",
+			wantKS: KeyStroke{Key: vterm.KeyKP5},
+			wantOK: true,
+		},
+		{
+			name:   "KP6",
+			inK:    "KP6",
+			wantKS: KeyStroke{Key: vterm.KeyKP6},
+			wantOK: true,
+		},
+		{
+			name:   "KP7",
+			inK:    "KP7",
+			wantKS: KeyStroke{Key: vterm.KeyKP7},
+			wantOK: true,
+		},
+		{
+			name:   "KP8",
+			inK:    "KP8",
+			wantKS: KeyStroke{Key: vterm.KeyKP8},
+			wantOK: true,
+		},
+		{
+			name:   "KP9",
+			inK:    "KP9",
+			wantKS: KeyStroke{Key: vterm.KeyKP9},
+			wantOK: true,
+		},
+		{
+			name:   "KPMult",
+			inK:    "KP*",
+			wantKS: KeyStroke{Key: vterm.KeyKPMult},
+			wantOK: true,
+		},
+		{
+			name:   "KPPlus",
+			inK:    "KP+",
+			wantKS: KeyStroke{Key: vterm.KeyKPPlus},
+			wantOK: true,
+		},
+		{
+			name:   "KPComma",
+			inK:    "KP,",
+			wantKS: KeyStroke{Key: vterm.KeyKPComma},
+			wantOK: true,
+		},
+		{
+			name:   "KPMinus",
+			inK:    "KP-",
+			wantKS: KeyStroke{Key: vterm.KeyKPMinus},
+			wantOK: true,
+		},
+		{
+			name:   "KPPeriod",
+			inK:    "KP.",
+			wantKS: KeyStroke{Key: vterm.KeyKPPeriod},
+			wantOK: true,
+		},
+		{
+			name:   "KPDivide",
+			inK:    "KP/",
+			wantKS: KeyStroke{Key: vterm.KeyKPDivide},
+			wantOK: true,
+		},
+		{
+			name:   "KPEnter",
+			inK:    "KPEnter",
+			wantKS: KeyStroke{Key: vterm.KeyKPEnter},
+			wantOK: true,
+		},
+		{
+			name:   "KPEqual",
+			inK:    "KP=",
+			wantKS: KeyStroke{Key: vterm.KeyKPEqual},
+			wantOK: true,
+		},
+		{
+			name:   "F13",
+			inK:    "F13",
+			wantKS: KeyStroke{Key: vterm.KeyFunction1, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "F14",
+			inK:    "F14",
+			wantKS: KeyStroke{Key: vterm.KeyFunction2, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "F15",
+			inK:    "F15",
+			wantKS: KeyStroke{Key: vterm.KeyFunction3, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "F16",
+			inK:    "F16",
+			wantKS: KeyStroke{Key: vterm.KeyFunction4, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "F17",
+			inK:    "F17",
+			wantKS: KeyStroke{Key: vterm.KeyFunction5, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "F18",
+			inK:    "F18",
+			wantKS: KeyStroke{Key: vterm.KeyFunction6, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "F19",
+			inK:    "F19",
+			wantKS: KeyStroke{Key: vterm.KeyFunction7, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "F20",
+			inK:    "F20",
+			wantKS: KeyStroke{Key: vterm.KeyFunction8, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "F21",
+			inK:    "F21",
+			wantKS: KeyStroke{Key: vterm.KeyFunction9, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "F22",
+			inK:    "F22",
+			wantKS: KeyStroke{Key: vterm.KeyFunction10, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "F23",
+			inK:    "F23",
+			wantKS: KeyStroke{Key: vterm.KeyFunction11, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "F24",
+			inK:    "F24",
+			wantKS: KeyStroke{Key: vterm.KeyFunction12, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "Space",
+			inK:    "Space",
+			wantKS: KeyStroke{Rune: ' '},
+			wantOK: true,
+		},
+		{
+			name:   "Rune",
+			inK:    "a",
+			wantKS: KeyStroke{Rune: 'a'},
+			wantOK: true,
+		},
+		{
+			name:   "RuneMultiByte",
+			inK:    "あ",
+			wantKS: KeyStroke{Rune: 'あ'},
+			wantOK: true,
+		},
+		{
+			name:   "RuneMinus",
+			inK:    "-",
+			wantKS: KeyStroke{Rune: '-'},
+			wantOK: true,
+		},
+		{
+			name:   "RunePlus",
+			inK:    "+",
+			wantKS: KeyStroke{Rune: '+'},
+			wantOK: true,
+		},
+		{
+			name:   "RuneC",
+			inK:    "C",
+			wantKS: KeyStroke{Rune: 'C'},
+			wantOK: true,
+		},
+		{
+			name:   "CtrlRune",
+			inK:    "C-a",
+			wantKS: KeyStroke{Rune: 'a', Mod: vterm.ModCtrl},
+			wantOK: true,
+		},
+		{
+			name:   "AltRune",
+			inK:    "M-x",
+			wantKS: KeyStroke{Rune: 'x', Mod: vterm.ModAlt},
+			wantOK: true,
+		},
+		{
+			name:   "ShiftKey",
+			inK:    "S-F5",
+			wantKS: KeyStroke{Key: vterm.KeyFunction5, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "ShiftF13",
+			inK:    "S-F13",
+			wantKS: KeyStroke{Key: vterm.KeyFunction1, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "CtrlF24",
+			inK:    "C-F24",
+			wantKS: KeyStroke{Key: vterm.KeyFunction12, Mod: vterm.ModCtrl | vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "CtrlMinus",
+			inK:    "C--",
+			wantKS: KeyStroke{Rune: '-', Mod: vterm.ModCtrl},
+			wantOK: true,
+		},
+		{
+			name:   "CtrlKPMinus",
+			inK:    "C-KP-",
+			wantKS: KeyStroke{Key: vterm.KeyKPMinus, Mod: vterm.ModCtrl},
+			wantOK: true,
+		},
+		{
+			name:   "CtrlSpace",
+			inK:    "C-Space",
+			wantKS: KeyStroke{Rune: ' ', Mod: vterm.ModCtrl},
+			wantOK: true,
+		},
+		{
+			name:   "CtrlAt",
+			inK:    "Ctrl-@",
+			wantKS: KeyStroke{Rune: ' ', Mod: vterm.ModCtrl},
+			wantOK: true,
+		},
+		{
+			name:   "CtrlAtShort",
+			inK:    "C-@",
+			wantKS: KeyStroke{Rune: ' ', Mod: vterm.ModCtrl},
+			wantOK: true,
+		},
+		{
+			name:   "AltAt",
+			inK:    "M-@",
+			wantKS: KeyStroke{Rune: '@', Mod: vterm.ModAlt},
+			wantOK: true,
+		},
+		{
+			name:   "ShiftTab",
+			inK:    "Shift+Tab",
+			wantKS: KeyStroke{Key: vterm.KeyTab, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "ShiftTabDash",
+			inK:    "Shift-Tab",
+			wantKS: KeyStroke{Key: vterm.KeyTab, Mod: vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "CtrlPlus",
+			inK:    "Ctrl+Enter",
+			wantKS: KeyStroke{Key: vterm.KeyEnter, Mod: vterm.ModCtrl},
+			wantOK: true,
+		},
+		{
+			name:   "AltPlus",
+			inK:    "Alt+ArrowUp",
+			wantKS: KeyStroke{Key: vterm.KeyUp, Mod: vterm.ModAlt},
+			wantOK: true,
+		},
+		{
+			name:   "AltDash",
+			inK:    "Alt-b",
+			wantKS: KeyStroke{Rune: 'b', Mod: vterm.ModAlt},
+			wantOK: true,
+		},
+		{
+			name:   "AllMods",
+			inK:    "C-M-S-Delete",
+			wantKS: KeyStroke{Key: vterm.KeyDel, Mod: vterm.ModCtrl | vterm.ModAlt | vterm.ModShift},
+			wantOK: true,
+		},
+		{
+			name:   "MixedMods",
+			inK:    "Ctrl+M-a",
+			wantKS: KeyStroke{Rune: 'a', Mod: vterm.ModCtrl | vterm.ModAlt},
+			wantOK: true,
+		},
+		{
+			name:   "ModAsRune",
+			inK:    "C-C",
+			wantKS: KeyStroke{Rune: 'C', Mod: vterm.ModCtrl},
+			wantOK: true,
+		},
+		{
+			name:   "ErrorEmpty",
+			inK:    "",
+			wantKS: KeyStroke{},
+			wantOK: false,
+		},
+		{
+			name:   "ErrorUnknown",
+			inK:    "Nonexistent",
+			wantKS: KeyStroke{},
+			wantOK: false,
+		},
+		{
+			name:   "ErrorPrefixOnly",
+			inK:    "C-",
+			wantKS: KeyStroke{},
+			wantOK: false,
+		},
+		{
+			name:   "ErrorDuplicateMod",
+			inK:    "C-C-a",
+			wantKS: KeyStroke{},
+			wantOK: false,
+		},
+		{
+			name:   "ErrorDuplicateModLong",
+			inK:    "Ctrl+C-a",
+			wantKS: KeyStroke{},
+			wantOK: false,
+		},
+		{
+			name:   "ErrorUnknownAfterMod",
+			inK:    "C-Nonexistent",
+			wantKS: KeyStroke{},
+			wantOK: false,
+		},
+		{
+			name:   "ErrorLowercase",
+			inK:    "enter",
+			wantKS: KeyStroke{},
+			wantOK: false,
+		},
+		{
+			name:   "ErrorF0",
+			inK:    "F0",
+			wantKS: KeyStroke{},
+			wantOK: false,
+		},
+		{
+			name:   "ErrorF25",
+			inK:    "F25",
+			wantKS: KeyStroke{},
+			wantOK: false,
+		},
+		{
+			name:   "ErrorMultiRune",
+			inK:    "C-ab",
+			wantKS: KeyStroke{},
+			wantOK: false,
+		},
+		{
+			name:   "ErrorInvalid",
+			inK:    "\x80",
+			wantKS: KeyStroke{},
+			wantOK: false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			gotKS, gotOK := tc.inK.Parse()
+			if gotKS != tc.wantKS {
+				t.Errorf("ks: expected %#v, got %#v", tc.wantKS, gotKS)
+			}
+			if gotOK != tc.wantOK {
+				t.Errorf("ok: expected %t, got %t", tc.wantOK, gotOK)
+			}
+		})
+	}
+}
+
+func TestKeyParseVTermKeyMap(t *testing.T) {
+	for k, vk := range vtermKeyMap {
+		for _, p := range keyModPrefixes {
+			want := KeyStroke{Key: vk, Mod: p.mod}
+			got, ok := (Key(p.prefix) + k).Parse()
+			if !ok || got != want {
+				t.Errorf("%s%s: expected %#v, got %#v (ok %t)", p.prefix, k, want, got, ok)
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+//go:build linux
+
+package main
+
+import (
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	// A socket file left behind by a previous run would make bind(2) fail.
+	// Only sockets are removed so that a mistyped path cannot destroy data.
+	fi, err := os.Lstat(path)
+	if err == nil && fi.Mode()&os.ModeSocket != 0 {
+		err = os.Remove(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// bind(2) creates the socket file honoring the umask. Setting it from
+	// mode creates the socket with its final permissions, so that there is
+	// no window in which other users could connect. The umask is process
+	// wide, but nothing else creates files while the server starts up.
+	old := unix.Umask(int(0o777 &^ mode.Perm()))
+	lis, err := net.Listen("unix", path)
+	unix.Umask(old)
+	if err != nil {
+		return nil, err
+	}
+	return lis, nil
+}
@@ -0,0 +1,92 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnixMode(t *testing.T) {
+	tt := []struct {
+		name   string
+		inMode os.FileMode
+	}{
+		{name: "Owner", inMode: 0o600},
+		{name: "Group", inMode: 0o660},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sock")
+			lis, err := listenUnix(path, tc.inMode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer lis.Close()
+
+			fi, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode()&os.ModeSocket == 0 {
+				t.Errorf("mode: expected socket, got %s", fi.Mode())
+			}
+			if got := fi.Mode().Perm(); got != tc.inMode {
+				t.Errorf("perm: expected %s, got %s", tc.inMode, got)
+			}
+		})
+	}
+}
+
+func TestListenUnixStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	err = stale.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := listenUnix(path, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+}
+
+func TestListenUnixRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sock")
+	data := []byte("data")
+	err := os.WriteFile(path, data, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := listenUnix(path, 0o600)
+	if err == nil {
+		_ = lis.Close()
+		t.Fatal("err: expected error, got nil")
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("data: expected %#v, got %#v", data, got)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	return nil, errors.New("unix domain socket is not supported on this platform")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/url"
+)
+
+// secretParams lists query parameters whose values are not written to the
+// access log. Keyboard input is included, since it may carry passwords
+// typed into the terminal.
+var secretParams = map[string]bool{
+	"data":  true,
+	"key":   true,
+	"mod":   true,
+	"text":  true,
+	"token": true,
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// NewLogger returns a logger writing to w in the given format, either
+// "text" or "json".
+func NewLogger(w io.Writer, format string, level slog.Level) (*slog.Logger, bool) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), true
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), true
+	default:
+		return nil, false
+	}
+}
+
+func redactQuery(query url.Values) string {
+	v := url.Values{}
+	for k, vs := range query {
+		if secretParams[k] {
+			v[k] = []string{"REDACTED"}
+			continue
+		}
+		v[k] = vs
+	}
+	return v.Encode()
+}
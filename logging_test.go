@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	tt := []struct {
+		name     string
+		inFormat string
+		inLevel  slog.Level
+		wantOK   bool
+		wantOut  bool
+		wantJSON bool
+	}{
+		{name: "Default", inFormat: "", inLevel: slog.LevelInfo, wantOK: true, wantOut: true},
+		{name: "Text", inFormat: "text", inLevel: slog.LevelInfo, wantOK: true, wantOut: true},
+		{name: "JSON", inFormat: "json", inLevel: slog.LevelInfo, wantOK: true, wantOut: true, wantJSON: true},
+		{name: "Level", inFormat: "text", inLevel: slog.LevelWarn, wantOK: true, wantOut: false},
+		{name: "Invalid", inFormat: "xml", inLevel: slog.LevelInfo, wantOK: false},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			logger, ok := NewLogger(buf, tc.inFormat, tc.inLevel)
+			if ok != tc.wantOK {
+				t.Fatalf("ok: expected %t, got %t", tc.wantOK, ok)
+			}
+			if !ok {
+				return
+			}
+
+			logger.Info("hello", "key", "value")
+			gotOut := buf.Len() > 0
+			if gotOut != tc.wantOut {
+				t.Errorf("output: expected %t, got %#v", tc.wantOut, buf.String())
+			}
+			if tc.wantJSON && !json.Valid(buf.Bytes()) {
+				t.Errorf("output: expected json, got %#v", buf.String())
+			}
+		})
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	tt := []struct {
+		name    string
+		inQuery url.Values
+		want    string
+	}{
+		{
+			name:    "Nil",
+			inQuery: nil,
+			want:    "",
+		},
+		{
+			name:    "Plain",
+			inQuery: url.Values{"name": {"a"}, "since": {"1"}},
+			want:    "name=a&since=1",
+		},
+		{
+			name:    "Secret",
+			inQuery: url.Values{"data": {"password"}, "token": {"t1", "t2"}, "x": {"y"}},
+			want:    "data=REDACTED&token=REDACTED&x=y",
+		},
+		{
+			name:    "Input",
+			inQuery: url.Values{"key": {"p"}, "mod": {"4"}, "text": {"password"}},
+			want:    "key=REDACTED&mod=REDACTED&text=REDACTED",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := redactQuery(tc.inQuery)
+			if got != tc.want {
+				t.Errorf("expected %#v, got %#v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestServiceHandlerAccessLog(t *testing.T) {
+	tt := []struct {
+		name     string
+		inCode   int
+		inLevel  slog.Level
+		wantLine string
+	}{
+		{
+			name:     "Success",
+			inCode:   http.StatusOK,
+			inLevel:  slog.LevelDebug,
+			wantLine: "level=DEBUG msg=request service=mock method=GET path=/mock",
+		},
+		{
+			name:     "SuccessHidden",
+			inCode:   http.StatusOK,
+			inLevel:  slog.LevelInfo,
+			wantLine: "",
+		},
+		{
+			name:     "Error",
+			inCode:   http.StatusBadRequest,
+			inLevel:  slog.LevelInfo,
+			wantLine: "level=INFO msg=request service=mock method=GET path=/mock",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			logbuf := new(bytes.Buffer)
+			logger, _ := NewLogger(logbuf, "text", tc.inLevel)
+			h := &ServiceHandler{
+				Service: &MockService{Resp: &ServiceResponse{Code: tc.inCode}},
+				Name:    "mock",
+				Logger:  logger,
+			}
+
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/mock?key=p&token=secret", nil))
+
+			got := logbuf.String()
+			if tc.wantLine == "" {
+				if got != "" {
+					t.Errorf("expected no log, got %#v", got)
+				}
+				return
+			}
+			for _, want := range []string{
+				tc.wantLine,
+				`params="key=REDACTED&token=REDACTED"`,
+				"status=" + strconv.Itoa(tc.inCode),
+				"duration=",
+			} {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %#v in %#v", want, got)
+				}
+			}
+			if strings.Contains(got, "secret") {
+				t.Errorf("secret leaked: %#v", got)
+			}
+		})
+	}
+}
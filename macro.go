@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gcrtnst/sw-term-server/internal/vterm"
+)
+
+// MaxMacroDelay limits the total delay of a macro, as the terminal is
+// locked while a macro runs.
+const MaxMacroDelay = 5 * time.Second
+
+// MacroStep is one step of a macro: a key press, a text typed rune by
+// rune, or a delay. Exactly one of Key, Text and Delay is set.
+type MacroStep struct {
+	Key   Key
+	Mod   vterm.Modifier
+	Text  string
+	Delay time.Duration
+}
+
+type Macro []MacroStep
+
+type macroStepJSON struct {
+	Key   Key            `json:"key"`
+	Mod   vterm.Modifier `json:"mod"`
+	Text  string         `json:"text"`
+	Delay string         `json:"delay"`
+}
+
+func (m *Macro) UnmarshalJSON(b []byte) error {
+	var steps []macroStepJSON
+	err := json.Unmarshal(b, &steps)
+	if err != nil {
+		return err
+	}
+
+	macro := make(Macro, 0, len(steps))
+	for i, sj := range steps {
+		step, err := sj.step()
+		if err != nil {
+			return fmt.Errorf("step %d: %w", i, err)
+		}
+		macro = append(macro, step)
+	}
+
+	err = macro.Validate()
+	if err != nil {
+		return err
+	}
+
+	*m = macro
+	return nil
+}
+
+func (sj macroStepJSON) step() (MacroStep, error) {
+	n := 0
+	for _, set := range []bool{sj.Key != "", sj.Text != "", sj.Delay != ""} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return MacroStep{}, errors.New("expected exactly one of key, text and delay")
+	}
+	if sj.Mod != vterm.ModNone && sj.Key == "" {
+		return MacroStep{}, errors.New("mod without key")
+	}
+
+	step := MacroStep{Key: sj.Key, Mod: sj.Mod, Text: sj.Text}
+	if sj.Delay != "" {
+		d, err := time.ParseDuration(sj.Delay)
+		if err != nil {
+			return MacroStep{}, err
+		}
+		step.Delay = d
+	}
+	return step, nil
+}
+
+// Validate checks that every key is known and that the delays are within
+// MaxMacroDelay.
+func (m Macro) Validate() error {
+	var total time.Duration
+	for i, step := range m {
+		if step.Key != "" && !step.Key.Valid() {
+			return fmt.Errorf("step %d: invalid key %q", i, step.Key)
+		}
+		if step.Delay < 0 {
+			return fmt.Errorf("step %d: negative delay", i)
+		}
+		total += step.Delay
+	}
+	if total > MaxMacroDelay {
+		return fmt.Errorf("total delay %s exceeds %s", total, MaxMacroDelay)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gcrtnst/sw-term-server/internal/vterm"
+)
+
+func TestMacroUnmarshalJSON(t *testing.T) {
+	tt := []struct {
+		name    string
+		in      string
+		want    Macro
+		wantErr bool
+	}{
+		{
+			name: "Normal",
+			in:   `[{"key": "A", "mod": 4}, {"text": "ls"}, {"delay": "100ms"}, {"key": "Enter"}]`,
+			want: Macro{
+				{Key: "A", Mod: vterm.ModCtrl},
+				{Text: "ls"},
+				{Delay: 100 * time.Millisecond},
+				{Key: "Enter"},
+			},
+		},
+		{
+			name: "Empty",
+			in:   `[]`,
+			want: Macro{},
+		},
+		{
+			name:    "InvalidKey",
+			in:      `[{"key": "Nonexistent"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "NoAction",
+			in:      `[{}]`,
+			wantErr: true,
+		},
+		{
+			name:    "MultipleActions",
+			in:      `[{"key": "A", "text": "A"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "ModWithoutKey",
+			in:      `[{"text": "A", "mod": 4}]`,
+			wantErr: true,
+		},
+		{
+			name:    "InvalidDelay",
+			in:      `[{"delay": "1"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "NegativeDelay",
+			in:      `[{"delay": "-1s"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "TooLong",
+			in:      `[{"delay": "3s"}, {"delay": "3s"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "NotArray",
+			in:      `{"key": "A"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var got Macro
+			err := got.UnmarshalJSON([]byte(tc.in))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err: expected %t, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %#v, got %#v", tc.want, got)
+			}
+		})
+	}
+}
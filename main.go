@@ -3,18 +3,38 @@ package main
 import (
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
+	"strings"
 
+	"github.com/gcrtnst/sw-term-server/internal/vterm"
 	"github.com/gcrtnst/sw-term-server/internal/xpty"
 )
 
 func main() {
+	addr := flag.String("addr", "127.0.0.1", "listen address")
 	port := flag.Int("port", 0, "listen port")
+	socket := flag.String("unix", "", "unix domain socket `path` (Linux only)")
+	socketMode := flag.String("unix-mode", "0600", "unix domain socket file mode")
 	row := flag.Int("row", 27, "terminal rows")
 	col := flag.Int("col", 58, "terminal columns")
 	shell := flag.String("shell", defaultShell(), "shell")
+	oscAllow := flag.String("osc-allow", "", "comma-separated OSC numbers to pass through to /osc")
+	dcsAllow := flag.Bool("dcs-allow", false, "pass through DCS sequences to /osc")
+	inputChunk := flag.Int("input-chunk", DefaultThrottleChunkSize, "maximum bytes of pty output processed at once")
+	inputRate := flag.Int("input-rate", 0, "maximum bytes of pty output processed per second (0 for unlimited)")
+	inputCoalesce := flag.Duration("input-coalesce", 0, "delay for coalescing bursts of pty output")
+	logFormat := flag.String("log-format", "text", "log format (text, json)")
+	logLevel := flag.String("log-level", "info", "log level (debug, info, warn, error)")
+	controlTokens := flag.String("control-tokens", "", "comma-separated tokens allowing full control")
+	viewTokens := flag.String("view-tokens", "", "comma-separated tokens allowing view-only access")
+	config := flag.String("config", "", "profile config `file` (JSON)")
+	profiles := ProfileFlag{}
+	flag.Var(profiles, "profile", "command startable from /start, as `name=command args...` (repeatable)")
+	theme := flag.String("theme", DefaultThemeName, "color theme ("+strings.Join(ThemeNames(), ", ")+")")
 	flag.Parse()
 
 	if *row <= 0 {
@@ -29,24 +49,141 @@ func main() {
 		fmt.Fprintln(os.Stderr, "shell not specified")
 		os.Exit(1)
 	}
+	if *inputChunk <= 0 {
+		fmt.Fprintln(os.Stderr, "invalid input-chunk")
+		os.Exit(1)
+	}
+	if *inputRate < 0 {
+		fmt.Fprintln(os.Stderr, "invalid input-rate")
+		os.Exit(1)
+	}
+	if *inputRate > 0 && *inputChunk > *inputRate {
+		fmt.Fprintln(os.Stderr, "input-chunk must not exceed input-rate")
+		os.Exit(1)
+	}
+	if *inputCoalesce < 0 {
+		fmt.Fprintln(os.Stderr, "invalid input-coalesce")
+		os.Exit(1)
+	}
+	if _, ok := LookupTheme(*theme); !ok {
+		fmt.Fprintln(os.Stderr, "invalid theme")
+		os.Exit(1)
+	}
+	osc, err := parseIntList(*oscAllow)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid osc-allow")
+		os.Exit(1)
+	}
+	if len(osc) > vterm.OSCAllowMax {
+		fmt.Fprintf(os.Stderr, "too many osc-allow entries (max %d)\n", vterm.OSCAllowMax)
+		os.Exit(1)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid log-level")
+		os.Exit(1)
+	}
+	logger, ok := NewLogger(os.Stdout, *logFormat, level)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "invalid log-format")
+		os.Exit(1)
+	}
+	mode, err := strconv.ParseUint(*socketMode, 8, 32)
+	if err != nil || mode&^uint64(os.ModePerm) != 0 {
+		fmt.Fprintln(os.Stderr, "invalid unix-mode")
+		os.Exit(1)
+	}
 
-	cfg := MainConfig{
-		Port: *port,
-		TermConfig: TermConfig{
-			Open: xpty.Open,
-			Row:  *row,
-			Col:  *col,
+	registry := map[string]Profile{
+		DefaultProfileName: {
 			Cmd: xpty.Cmd{
 				Path: *shell,
 				Args: []string{*shell},
 			},
 		},
-		LogWriter: os.Stdout,
+	}
+	defaultName := DefaultProfileName
+	var macros map[string]Macro
+	if *config != "" {
+		pc, err := LoadProfileConfig(*config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid config: %s\n", err.Error())
+			os.Exit(1)
+		}
+		for name, p := range pc.Profiles {
+			registry[name] = p
+		}
+		if pc.Default != "" {
+			defaultName = pc.Default
+		}
+		macros = pc.Macros
+	}
+	for name, p := range profiles {
+		registry[name] = p
+	}
+	registry, err = ResolveProfiles(registry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid profile: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	base := TermConfig{
+		Open:     xpty.Open,
+		Row:      *row,
+		Col:      *col,
+		Theme:    *theme,
+		OSCAllow: osc,
+		DCSAllow: *dcsAllow,
+		Throttle: ThrottleConfig{
+			ChunkSize: *inputChunk,
+			Rate:      *inputRate,
+			Coalesce:  *inputCoalesce,
+		},
+		Profiles: registry,
+	}
+
+	cfg := MainConfig{
+		Addr:       *addr,
+		Port:       *port,
+		Socket:     *socket,
+		SocketMode: os.FileMode(mode),
+		TermConfig: registry[defaultName].Apply(base),
+		Macros:     macros,
+		Auth:       NewAuth(parseStringList(*controlTokens), parseStringList(*viewTokens)),
+		Logger:     logger,
 	}
 	code := Run(cfg)
 	os.Exit(code)
 }
 
+func parseIntList(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(s, ",")
+	list := make([]int, 0, len(fields))
+	for _, f := range fields {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, n)
+	}
+	return list, nil
+}
+
+func parseStringList(s string) []string {
+	var list []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f != "" {
+			list = append(list, f)
+		}
+	}
+	return list
+}
+
 func defaultShell() string {
 	if runtime.GOOS == "windows" {
 		comspec := os.Getenv("COMSPEC")
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var screenLatencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+type metricsRequestKey struct {
+	Service string
+	Code    int
+}
+
+// Metrics collects server counters for the /metrics endpoint. The methods
+// recording values are safe to call on a nil *Metrics and do nothing.
+type Metrics struct {
+	mu             sync.Mutex
+	requests       map[metricsRequestKey]uint64
+	screenBytes    uint64
+	keyboardEvents uint64
+	screenLatency  histogram
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:      map[metricsRequestKey]uint64{},
+		screenLatency: newHistogram(screenLatencyBuckets),
+	}
+}
+
+func (m *Metrics) ObserveRequest(service string, code int) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[metricsRequestKey{Service: service, Code: code}]++
+}
+
+func (m *Metrics) AddScreenBytes(n int) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.screenBytes += uint64(n)
+}
+
+func (m *Metrics) AddKeyboardEvent() {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keyboardEvents++
+}
+
+func (m *Metrics) ObserveScreenLatency(d time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.screenLatency.observe(d.Seconds())
+}
+
+// WriteText writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteText(w io.Writer, stats SlotStats) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ew := &errWriter{w: w}
+
+	ew.printf("# HELP swterm_requests_total Number of API requests.\n")
+	ew.printf("# TYPE swterm_requests_total counter\n")
+	keys := make([]metricsRequestKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Service != keys[j].Service {
+			return keys[i].Service < keys[j].Service
+		}
+		return keys[i].Code < keys[j].Code
+	})
+	for _, k := range keys {
+		ew.printf("swterm_requests_total{service=%q,code=\"%d\"} %d\n", k.Service, k.Code, m.requests[k])
+	}
+
+	writeCounter(ew, "swterm_screen_bytes_total", "Bytes of encoded screen data.", m.screenBytes)
+	writeCounter(ew, "swterm_keyboard_events_total", "Number of keyboard events sent to the terminal.", m.keyboardEvents)
+	writeCounter(ew, "swterm_pty_read_bytes_total", "Bytes read from the pty.", stats.PtyIn)
+	writeCounter(ew, "swterm_pty_processed_bytes_total", "Bytes read from the pty and processed by the terminal.", stats.PtyProcessed)
+	writeCounter(ew, "swterm_pty_processed_chunks_total", "Number of chunks of pty output processed by the terminal.", stats.PtyChunks)
+	writeCounter(ew, "swterm_pty_written_bytes_total", "Bytes written to the pty.", stats.PtyOut)
+	writeCounter(ew, "swterm_pty_dropped_bytes_total", "Bytes of keyboard input dropped because the pty was not reading.", stats.PtyDropped)
+	writeCounter(ew, "swterm_terminal_starts_total", "Number of terminal starts.", stats.Starts)
+	writeCounter(ew, "swterm_terminal_restarts_total", "Number of terminal restarts by the restart policy.", stats.Restarts)
+
+	ew.printf("# HELP swterm_screen_duration_seconds Time to capture and encode the screen.\n")
+	ew.printf("# TYPE swterm_screen_duration_seconds histogram\n")
+	m.screenLatency.write(ew, "swterm_screen_duration_seconds")
+
+	return ew.err
+}
+
+func writeCounter(ew *errWriter, name, help string, v uint64) {
+	ew.printf("# HELP %s %s\n", name, help)
+	ew.printf("# TYPE %s counter\n", name)
+	ew.printf("%s %d\n", name, v)
+}
+
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) histogram {
+	return histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.bounds {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) write(ew *errWriter, name string) {
+	for i, b := range h.bounds {
+		le := strconv.FormatFloat(b, 'g', -1, 64)
+		ew.printf("%s_bucket{le=%q} %d\n", name, le, h.counts[i])
+	}
+	ew.printf("%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	ew.printf("%s_sum %s\n", name, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	ew.printf("%s_count %d\n", name, h.count)
+}
+
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, a ...any) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, a...)
+}
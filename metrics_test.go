@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsWriteText(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveRequest("screen", http.StatusOK)
+	m.ObserveRequest("screen", http.StatusOK)
+	m.ObserveRequest("keyboard", http.StatusBadRequest)
+	m.ObserveRequest("keyboard", http.StatusOK)
+	m.AddScreenBytes(100)
+	m.AddScreenBytes(23)
+	m.AddKeyboardEvent()
+	m.ObserveScreenLatency(2 * time.Millisecond)
+	m.ObserveScreenLatency(2 * time.Second)
+
+	buf := new(bytes.Buffer)
+	err := m.WriteText(buf, SlotStats{Starts: 2, Restarts: 1, PtyIn: 10, PtyProcessed: 8, PtyChunks: 3, PtyOut: 20, PtyDropped: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"# HELP swterm_requests_total Number of API requests.",
+		"# TYPE swterm_requests_total counter",
+		`swterm_requests_total{service="keyboard",code="200"} 1`,
+		`swterm_requests_total{service="keyboard",code="400"} 1`,
+		`swterm_requests_total{service="screen",code="200"} 2`,
+		"# HELP swterm_screen_bytes_total Bytes of encoded screen data.",
+		"# TYPE swterm_screen_bytes_total counter",
+		"swterm_screen_bytes_total 123",
+		"# HELP swterm_keyboard_events_total Number of keyboard events sent to the terminal.",
+		"# TYPE swterm_keyboard_events_total counter",
+		"swterm_keyboard_events_total 1",
+		"# HELP swterm_pty_read_bytes_total Bytes read from the pty.",
+		"# TYPE swterm_pty_read_bytes_total counter",
+		"swterm_pty_read_bytes_total 10",
+		"# HELP swterm_pty_processed_bytes_total Bytes read from the pty and processed by the terminal.",
+		"# TYPE swterm_pty_processed_bytes_total counter",
+		"swterm_pty_processed_bytes_total 8",
+		"# HELP swterm_pty_processed_chunks_total Number of chunks of pty output processed by the terminal.",
+		"# TYPE swterm_pty_processed_chunks_total counter",
+		"swterm_pty_processed_chunks_total 3",
+		"# HELP swterm_pty_written_bytes_total Bytes written to the pty.",
+		"# TYPE swterm_pty_written_bytes_total counter",
+		"swterm_pty_written_bytes_total 20",
+		"# HELP swterm_pty_dropped_bytes_total Bytes of keyboard input dropped because the pty was not reading.",
+		"# TYPE swterm_pty_dropped_bytes_total counter",
+		"swterm_pty_dropped_bytes_total 5",
+		"# HELP swterm_terminal_starts_total Number of terminal starts.",
+		"# TYPE swterm_terminal_starts_total counter",
+		"swterm_terminal_starts_total 2",
+		"# HELP swterm_terminal_restarts_total Number of terminal restarts by the restart policy.",
+		"# TYPE swterm_terminal_restarts_total counter",
+		"swterm_terminal_restarts_total 1",
+		"# HELP swterm_screen_duration_seconds Time to capture and encode the screen.",
+		"# TYPE swterm_screen_duration_seconds histogram",
+		`swterm_screen_duration_seconds_bucket{le="0.001"} 0`,
+		`swterm_screen_duration_seconds_bucket{le="0.0025"} 1`,
+		`swterm_screen_duration_seconds_bucket{le="0.005"} 1`,
+		`swterm_screen_duration_seconds_bucket{le="0.01"} 1`,
+		`swterm_screen_duration_seconds_bucket{le="0.025"} 1`,
+		`swterm_screen_duration_seconds_bucket{le="0.05"} 1`,
+		`swterm_screen_duration_seconds_bucket{le="0.1"} 1`,
+		`swterm_screen_duration_seconds_bucket{le="0.25"} 1`,
+		`swterm_screen_duration_seconds_bucket{le="0.5"} 1`,
+		`swterm_screen_duration_seconds_bucket{le="1"} 1`,
+		`swterm_screen_duration_seconds_bucket{le="+Inf"} 2`,
+		"swterm_screen_duration_seconds_sum 2.002",
+		"swterm_screen_duration_seconds_count 2",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestMetricsNil(t *testing.T) {
+	var m *Metrics
+	m.ObserveRequest("screen", http.StatusOK)
+	m.AddScreenBytes(1)
+	m.AddKeyboardEvent()
+	m.ObserveScreenLatency(time.Millisecond)
+}
+
+func TestServiceHandlerMetrics(t *testing.T) {
+	m := NewMetrics()
+	h := &ServiceHandler{
+		Service: &MockService{Resp: &ServiceResponse{Code: http.StatusOK}},
+		Name:    "mock",
+		Metrics: m,
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/mock", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/mock", nil))
+
+	want := map[metricsRequestKey]uint64{
+		{Service: "mock", Code: http.StatusOK}:               1,
+		{Service: "mock", Code: http.StatusMethodNotAllowed}: 1,
+	}
+	if len(m.requests) != len(want) {
+		t.Errorf("requests: expected %#v, got %#v", want, m.requests)
+	}
+	for k, v := range want {
+		if m.requests[k] != v {
+			t.Errorf("requests: expected %#v, got %#v", want, m.requests)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/gcrtnst/sw-term-server/internal/xpty"
+)
+
+const DefaultProfileName = "shell"
+
+// MaxTermSize limits the rows and columns requested through /start.
+const MaxTermSize = 1000
+
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "never"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartAlways    RestartPolicy = "always"
+)
+
+// ShouldRestart reports whether a process that exited with code should be
+// started again. The zero value behaves like RestartNever.
+func (r RestartPolicy) ShouldRestart(code int) bool {
+	switch r {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return code != 0
+	default:
+		return false
+	}
+}
+
+func (r RestartPolicy) valid() bool {
+	switch r {
+	case "", RestartNever, RestartOnFailure, RestartAlways:
+		return true
+	default:
+		return false
+	}
+}
+
+// Profile is a command that clients may start by name. Only commands
+// listed as profiles on the server side can be started through /start.
+// Zero Row, Col and Theme keep the server defaults.
+type Profile struct {
+	Cmd      xpty.Cmd
+	Row, Col int
+	Theme    string
+	Restart  RestartPolicy
+}
+
+// Apply returns base with the settings of p.
+func (p Profile) Apply(base TermConfig) TermConfig {
+	cfg := base
+	cfg.Cmd = p.Cmd
+	if p.Row > 0 {
+		cfg.Row = p.Row
+	}
+	if p.Col > 0 {
+		cfg.Col = p.Col
+	}
+	if p.Theme != "" {
+		cfg.Theme = p.Theme
+	}
+	cfg.Restart = p.Restart
+	return cfg
+}
+
+// Resolve validates p and returns it with the command path looked up in
+// PATH, as the process is started without a PATH search.
+func (p Profile) Resolve() (Profile, error) {
+	path, err := exec.LookPath(p.Cmd.Path)
+	if err != nil {
+		return Profile{}, fmt.Errorf("command %q not found", p.Cmd.Path)
+	}
+
+	if p.Cmd.Dir != "" {
+		fi, err := os.Stat(p.Cmd.Dir)
+		if err != nil {
+			return Profile{}, fmt.Errorf("working directory %q not found", p.Cmd.Dir)
+		}
+		if !fi.IsDir() {
+			return Profile{}, fmt.Errorf("working directory %q is not a directory", p.Cmd.Dir)
+		}
+	}
+
+	row, col := p.Row, p.Col
+	if row == 0 {
+		row = 1
+	}
+	if col == 0 {
+		col = 1
+	}
+	if err := xpty.CheckSize(xpty.Size{Row: row, Col: col}); err != nil {
+		return Profile{}, fmt.Errorf("invalid size %dx%d", p.Row, p.Col)
+	}
+
+	if _, ok := LookupTheme(p.Theme); !ok {
+		return Profile{}, fmt.Errorf("unknown theme %q", p.Theme)
+	}
+
+	if !p.Restart.valid() {
+		return Profile{}, fmt.Errorf("unknown restart policy %q", p.Restart)
+	}
+
+	p.Cmd.Path = path
+	return p, nil
+}
+
+// ResolveProfiles resolves every profile, naming the offending profile in
+// the returned error.
+func ResolveProfiles(profiles map[string]Profile) (map[string]Profile, error) {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := make(map[string]Profile, len(profiles))
+	for _, name := range names {
+		p, err := profiles[name].Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+		resolved[name] = p
+	}
+	return resolved, nil
+}
+
+// ProfileConfig is the profile registry loaded from a config file, along
+// with the macros available through /macro.
+type ProfileConfig struct {
+	Default  string
+	Profiles map[string]Profile
+	Macros   map[string]Macro
+}
+
+type profileConfigJSON struct {
+	Default  string                     `json:"default"`
+	Profiles map[string]profileJSON     `json:"profiles"`
+	Macros   map[string]json.RawMessage `json:"macros"`
+}
+
+type profileJSON struct {
+	Path    string            `json:"path"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+	Dir     string            `json:"dir"`
+	Row     int               `json:"row"`
+	Col     int               `json:"col"`
+	Theme   string            `json:"theme"`
+	Restart RestartPolicy     `json:"restart"`
+}
+
+func LoadProfileConfig(path string) (ProfileConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ProfileConfig{}, err
+	}
+
+	cfg, err := ParseProfileConfig(b)
+	if err != nil {
+		return ProfileConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseProfileConfig parses a JSON profile config. Args exclude the command
+// itself, and Env is added to the environment of the server.
+func ParseProfileConfig(b []byte) (ProfileConfig, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	var v profileConfigJSON
+	err := dec.Decode(&v)
+	if err != nil {
+		return ProfileConfig{}, err
+	}
+
+	cfg := ProfileConfig{
+		Default:  v.Default,
+		Profiles: make(map[string]Profile, len(v.Profiles)),
+		Macros:   make(map[string]Macro, len(v.Macros)),
+	}
+	for name, pj := range v.Profiles {
+		if name == "" {
+			return ProfileConfig{}, errors.New("empty profile name")
+		}
+		if pj.Path == "" {
+			return ProfileConfig{}, fmt.Errorf("profile %q: missing path", name)
+		}
+
+		p := Profile{
+			Cmd: xpty.Cmd{
+				Path: pj.Path,
+				Args: append([]string{pj.Path}, pj.Args...),
+				Dir:  pj.Dir,
+			},
+			Row:     pj.Row,
+			Col:     pj.Col,
+			Theme:   pj.Theme,
+			Restart: pj.Restart,
+		}
+		if pj.Env != nil {
+			p.Cmd.Env = mergeEnv(os.Environ(), pj.Env)
+		}
+		cfg.Profiles[name] = p
+	}
+	for name, raw := range v.Macros {
+		var m Macro
+		err := json.Unmarshal(raw, &m)
+		if err != nil {
+			return ProfileConfig{}, fmt.Errorf("macro %q: %w", name, err)
+		}
+		cfg.Macros[name] = m
+	}
+	if cfg.Default != "" {
+		if _, ok := cfg.Profiles[cfg.Default]; !ok {
+			return ProfileConfig{}, fmt.Errorf("default profile %q not defined", cfg.Default)
+		}
+	}
+	return cfg, nil
+}
+
+func mergeEnv(base []string, env map[string]string) []string {
+	merged := make([]string, 0, len(base)+len(env))
+	for _, kv := range base {
+		k, _, _ := strings.Cut(kv, "=")
+		if _, ok := env[k]; ok {
+			continue
+		}
+		merged = append(merged, kv)
+	}
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		merged = append(merged, k+"="+env[k])
+	}
+	return merged
+}
+
+// ParseProfile parses a profile flag of the form "name=command args...".
+func ParseProfile(s string) (string, Profile, error) {
+	name, command, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return "", Profile{}, errors.New("expected name=command")
+	}
+
+	args := strings.Fields(command)
+	if len(args) <= 0 {
+		return "", Profile{}, errors.New("missing command")
+	}
+
+	p := Profile{
+		Cmd: xpty.Cmd{
+			Path: args[0],
+			Args: args,
+		},
+	}
+	return name, p, nil
+}
+
+// ProfileFlag collects repeated -profile flags.
+type ProfileFlag map[string]Profile
+
+func (f ProfileFlag) String() string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (f ProfileFlag) Set(s string) error {
+	name, p, err := ParseProfile(s)
+	if err != nil {
+		return err
+	}
+	f[name] = p
+	return nil
+}
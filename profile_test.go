@@ -0,0 +1,366 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gcrtnst/sw-term-server/internal/xpty"
+)
+
+func TestParseProfile(t *testing.T) {
+	tt := []struct {
+		name        string
+		in          string
+		wantName    string
+		wantProfile Profile
+		wantOK      bool
+	}{
+		{
+			name:     "Command",
+			in:       "htop=htop",
+			wantName: "htop",
+			wantProfile: Profile{
+				Cmd: xpty.Cmd{Path: "htop", Args: []string{"htop"}},
+			},
+			wantOK: true,
+		},
+		{
+			name:     "Args",
+			in:       "py=/usr/bin/python3  -q",
+			wantName: "py",
+			wantProfile: Profile{
+				Cmd: xpty.Cmd{Path: "/usr/bin/python3", Args: []string{"/usr/bin/python3", "-q"}},
+			},
+			wantOK: true,
+		},
+		{
+			name:   "NoEqual",
+			in:     "htop",
+			wantOK: false,
+		},
+		{
+			name:   "NoName",
+			in:     "=htop",
+			wantOK: false,
+		},
+		{
+			name:   "NoCommand",
+			in:     "htop= ",
+			wantOK: false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			gotName, gotProfile, err := ParseProfile(tc.in)
+			gotOK := err == nil
+			if gotOK != tc.wantOK {
+				t.Fatalf("ok: expected %t, got %t (%v)", tc.wantOK, gotOK, err)
+			}
+			if gotName != tc.wantName {
+				t.Errorf("name: expected %#v, got %#v", tc.wantName, gotName)
+			}
+			if !reflect.DeepEqual(gotProfile, tc.wantProfile) {
+				t.Errorf("profile: expected %#v, got %#v", tc.wantProfile, gotProfile)
+			}
+		})
+	}
+}
+
+func TestProfileFlag(t *testing.T) {
+	f := ProfileFlag{}
+	for _, s := range []string{"b=top", "a=htop", "b=btop"} {
+		err := f.Set(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := f.String(); got != "a,b" {
+		t.Errorf("string: expected %#v, got %#v", "a,b", got)
+	}
+	if got := f["b"].Cmd.Path; got != "btop" {
+		t.Errorf("b: expected %#v, got %#v", "btop", got)
+	}
+	if err := f.Set("bad"); err == nil {
+		t.Errorf("set: expected error")
+	}
+}
+
+func TestRestartPolicyShouldRestart(t *testing.T) {
+	tt := []struct {
+		inPolicy RestartPolicy
+		inCode   int
+		want     bool
+	}{
+		{inPolicy: "", inCode: 0, want: false},
+		{inPolicy: "", inCode: 1, want: false},
+		{inPolicy: RestartNever, inCode: 0, want: false},
+		{inPolicy: RestartNever, inCode: 1, want: false},
+		{inPolicy: RestartOnFailure, inCode: 0, want: false},
+		{inPolicy: RestartOnFailure, inCode: 1, want: true},
+		{inPolicy: RestartAlways, inCode: 0, want: true},
+		{inPolicy: RestartAlways, inCode: 1, want: true},
+	}
+
+	for _, tc := range tt {
+		got := tc.inPolicy.ShouldRestart(tc.inCode)
+		if got != tc.want {
+			t.Errorf("%#v, %d: expected %t, got %t", tc.inPolicy, tc.inCode, tc.want, got)
+		}
+	}
+}
+
+func TestProfileApply(t *testing.T) {
+	base := TermConfig{
+		Row:   27,
+		Col:   58,
+		Cmd:   xpty.Cmd{Path: "bash", Args: []string{"bash"}},
+		Theme: "default",
+	}
+
+	tt := []struct {
+		name      string
+		inProfile Profile
+		want      TermConfig
+	}{
+		{
+			name:      "Defaults",
+			inProfile: Profile{Cmd: xpty.Cmd{Path: "htop", Args: []string{"htop"}}},
+			want: TermConfig{
+				Row:   27,
+				Col:   58,
+				Cmd:   xpty.Cmd{Path: "htop", Args: []string{"htop"}},
+				Theme: "default",
+			},
+		},
+		{
+			name: "Override",
+			inProfile: Profile{
+				Cmd:     xpty.Cmd{Path: "htop", Args: []string{"htop"}, Env: []string{"A=B"}, Dir: "/tmp"},
+				Row:     10,
+				Col:     20,
+				Theme:   "gruvbox",
+				Restart: RestartAlways,
+			},
+			want: TermConfig{
+				Row:     10,
+				Col:     20,
+				Cmd:     xpty.Cmd{Path: "htop", Args: []string{"htop"}, Env: []string{"A=B"}, Dir: "/tmp"},
+				Theme:   "gruvbox",
+				Restart: RestartAlways,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.inProfile.Apply(base)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %#v, got %#v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestProfileResolve(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	err = os.WriteFile(file, nil, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name      string
+		inProfile Profile
+		wantErr   string
+	}{
+		{
+			name:      "Normal",
+			inProfile: Profile{Cmd: xpty.Cmd{Path: exe, Dir: dir}, Row: 10, Col: 20, Theme: "gruvbox", Restart: RestartOnFailure},
+			wantErr:   "",
+		},
+		{
+			name:      "NoCommand",
+			inProfile: Profile{Cmd: xpty.Cmd{Path: filepath.Join(dir, "nonexistent")}},
+			wantErr:   "command",
+		},
+		{
+			name:      "NoDir",
+			inProfile: Profile{Cmd: xpty.Cmd{Path: exe, Dir: filepath.Join(dir, "nonexistent")}},
+			wantErr:   "working directory",
+		},
+		{
+			name:      "NotDir",
+			inProfile: Profile{Cmd: xpty.Cmd{Path: exe, Dir: file}},
+			wantErr:   "not a directory",
+		},
+		{
+			name:      "NegativeSize",
+			inProfile: Profile{Cmd: xpty.Cmd{Path: exe}, Row: -1},
+			wantErr:   "invalid size",
+		},
+		{
+			name:      "LargeSize",
+			inProfile: Profile{Cmd: xpty.Cmd{Path: exe}, Col: 1 << 20},
+			wantErr:   "invalid size",
+		},
+		{
+			name:      "Theme",
+			inProfile: Profile{Cmd: xpty.Cmd{Path: exe}, Theme: "nonexistent"},
+			wantErr:   "unknown theme",
+		},
+		{
+			name:      "Restart",
+			inProfile: Profile{Cmd: xpty.Cmd{Path: exe}, Restart: "sometimes"},
+			wantErr:   "unknown restart policy",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.inProfile.Resolve()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				if got.Cmd.Path != exe {
+					t.Errorf("path: expected %#v, got %#v", exe, got.Cmd.Path)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("err: expected %#v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResolveProfiles(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ResolveProfiles(map[string]Profile{
+		"good": {Cmd: xpty.Cmd{Path: exe}},
+		"bad":  {Cmd: xpty.Cmd{Path: exe}, Theme: "nonexistent"},
+	})
+	want := `profile "bad": unknown theme "nonexistent"`
+	if err == nil || err.Error() != want {
+		t.Errorf("expected %#v, got %v", want, err)
+	}
+}
+
+func TestParseProfileConfig(t *testing.T) {
+	t.Setenv("SWTERM_TEST_KEEP", "1")
+	t.Setenv("SWTERM_TEST_OVERRIDE", "old")
+
+	tt := []struct {
+		name    string
+		in      string
+		want    ProfileConfig
+		wantErr bool
+	}{
+		{
+			name: "Normal",
+			in: `{
+				"default": "htop",
+				"profiles": {
+					"htop": {"path": "/usr/bin/htop", "args": ["-d", "10"], "dir": "/tmp", "row": 24, "col": 80, "theme": "gruvbox", "restart": "always"}
+				}
+			}`,
+			want: ProfileConfig{
+				Default: "htop",
+				Profiles: map[string]Profile{
+					"htop": {
+						Cmd:     xpty.Cmd{Path: "/usr/bin/htop", Args: []string{"/usr/bin/htop", "-d", "10"}, Dir: "/tmp"},
+						Row:     24,
+						Col:     80,
+						Theme:   "gruvbox",
+						Restart: RestartAlways,
+					},
+				},
+				Macros: map[string]Macro{},
+			},
+		},
+		{
+			name: "Macros",
+			in: `{
+				"profiles": {"sh": {"path": "/bin/sh"}},
+				"macros": {"quit": [{"key": "Escape"}, {"text": ":q"}, {"key": "Enter"}]}
+			}`,
+			want: ProfileConfig{
+				Profiles: map[string]Profile{
+					"sh": {Cmd: xpty.Cmd{Path: "/bin/sh", Args: []string{"/bin/sh"}}},
+				},
+				Macros: map[string]Macro{
+					"quit": {{Key: "Escape"}, {Text: ":q"}, {Key: "Enter"}},
+				},
+			},
+		},
+		{
+			name:    "InvalidMacro",
+			in:      `{"profiles": {"sh": {"path": "/bin/sh"}}, "macros": {"quit": [{"key": "Nonexistent"}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "MissingPath",
+			in:      `{"profiles": {"htop": {"args": ["-d"]}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "UnknownField",
+			in:      `{"profiles": {"htop": {"path": "htop", "cmd": "htop"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "UndefinedDefault",
+			in:      `{"default": "vim", "profiles": {"htop": {"path": "htop"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Syntax",
+			in:      `{"profiles": `,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseProfileConfig([]byte(tc.in))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err: expected %t, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %#v, got %#v", tc.want, got)
+			}
+		})
+	}
+
+	got, err := ParseProfileConfig([]byte(`{"profiles": {"sh": {"path": "sh", "env": {"SWTERM_TEST_OVERRIDE": "new", "SWTERM_TEST_ADD": "2"}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := strings.Join(got.Profiles["sh"].Cmd.Env, "\n") + "\n"
+	for _, want := range []string{"SWTERM_TEST_KEEP=1\n", "SWTERM_TEST_OVERRIDE=new\n", "SWTERM_TEST_ADD=2\n"} {
+		if !strings.Contains(env, want) {
+			t.Errorf("env: expected %#v", want)
+		}
+	}
+	if strings.Contains(env, "SWTERM_TEST_OVERRIDE=old") {
+		t.Errorf("env: old value not overridden")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/gcrtnst/sw-term-server/internal/vterm"
+)
+
+const MaxQuantizeColors = 16
+
+// PaletteSpec is a target palette for quantization. Either Count selects
+// the first Count colors of the terminal palette, or Colors lists them
+// explicitly.
+type PaletteSpec struct {
+	Count  int
+	Colors []vterm.Color
+}
+
+// ParsePaletteSpec parses either a color count ("8" or "16") or a
+// comma-separated list of up to 16 hex colors ("000000,#FF0000,...").
+func ParsePaletteSpec(s string) (PaletteSpec, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n != 8 && n != 16 {
+			return PaletteSpec{}, false
+		}
+		return PaletteSpec{Count: n}, true
+	}
+
+	fields := strings.Split(s, ",")
+	if len(fields) > MaxQuantizeColors {
+		return PaletteSpec{}, false
+	}
+
+	colors := make([]vterm.Color, 0, len(fields))
+	for _, f := range fields {
+		f = strings.TrimPrefix(f, "#")
+		if len(f) != 6 {
+			return PaletteSpec{}, false
+		}
+		b, err := hex.DecodeString(f)
+		if err != nil {
+			return PaletteSpec{}, false
+		}
+		colors = append(colors, vterm.NewColorRGB(b[0], b[1], b[2]))
+	}
+	return PaletteSpec{Colors: colors}, true
+}
+
+func (spec PaletteSpec) Resolve(pal Theme) []vterm.Color {
+	if spec.Colors != nil {
+		return spec.Colors
+	}
+
+	colors := make([]vterm.Color, spec.Count)
+	for i := range colors {
+		colors[i] = resolveColor(pal.Palette[i], pal)
+	}
+	return colors
+}
+
+// ResolveColors converts every cell color in ss to RGB using pal.
+// Indexed colors beyond the 16-color palette must already be resolved.
+func ResolveColors(ss vterm.ScreenShot, pal Theme) vterm.ScreenShot {
+	cell := make([]vterm.Cell, len(ss.Cell))
+	copy(cell, ss.Cell)
+	for idx := range cell {
+		cell[idx].FG = resolveColor(cell[idx].FG, pal)
+		cell[idx].BG = resolveColor(cell[idx].BG, pal)
+	}
+
+	ss.Cell = cell
+	return ss
+}
+
+// Quantize maps every RGB cell color in ss to the nearest color in target.
+// The returned cells use indexed colors referring to target.
+func Quantize(ss vterm.ScreenShot, target []vterm.Color) vterm.ScreenShot {
+	cell := make([]vterm.Cell, len(ss.Cell))
+	copy(cell, ss.Cell)
+	for idx := range cell {
+		cell[idx].FG = vterm.NewColorIndexed(uint8(NearestColor(cell[idx].FG, target)))
+		cell[idx].BG = vterm.NewColorIndexed(uint8(NearestColor(cell[idx].BG, target)))
+	}
+
+	ss.Cell = cell
+	return ss
+}
+
+// QuantizeTheme returns the palette for a screenshot quantized to target.
+// The default colors are replaced by their nearest entries in target.
+func QuantizeTheme(pal Theme, target []vterm.Color) Theme {
+	var th Theme
+	copy(th.Palette[:], target)
+	th.FG = target[NearestColor(resolveColor(pal.FG, pal), target)]
+	th.BG = target[NearestColor(resolveColor(pal.BG, pal), target)]
+	return th
+}
+
+// NearestColor returns the index of the color in target closest to col,
+// using the "redmean" approximation of perceptual distance.
+func NearestColor(col vterm.Color, target []vterm.Color) int {
+	best, bestDist := 0, -1
+	for i, t := range target {
+		d := colorDistance(col, t)
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+func colorDistance(a, b vterm.Color) int {
+	rmean := (int(a.Red) + int(b.Red)) / 2
+	dr := int(a.Red) - int(b.Red)
+	dg := int(a.Green) - int(b.Green)
+	db := int(a.Blue) - int(b.Blue)
+	return (((512 + rmean) * dr * dr) >> 8) + 4*dg*dg + (((767 - rmean) * db * db) >> 8)
+}
+
+func resolveColor(col vterm.Color, pal Theme) vterm.Color {
+	switch {
+	case col.IsDefaultFG():
+		col = pal.FG
+	case col.IsDefaultBG():
+		col = pal.BG
+	case col.IsIndexed() && col.Idx < 16:
+		col = pal.Palette[col.Idx]
+	}
+	return vterm.NewColorRGB(col.Red, col.Green, col.Blue)
+}
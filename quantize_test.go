@@ -0,0 +1,185 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gcrtnst/sw-term-server/internal/vterm"
+)
+
+func TestParsePaletteSpec(t *testing.T) {
+	tt := []struct {
+		name     string
+		in       string
+		wantSpec PaletteSpec
+		wantOK   bool
+	}{
+		{
+			name:     "Count8",
+			in:       "8",
+			wantSpec: PaletteSpec{Count: 8},
+			wantOK:   true,
+		},
+		{
+			name:     "Count16",
+			in:       "16",
+			wantSpec: PaletteSpec{Count: 16},
+			wantOK:   true,
+		},
+		{
+			name:     "CountInvalid",
+			in:       "4",
+			wantSpec: PaletteSpec{},
+			wantOK:   false,
+		},
+		{
+			name: "Colors",
+			in:   "000000,#FF8000,c4c4c4",
+			wantSpec: PaletteSpec{
+				Colors: []vterm.Color{
+					vterm.NewColorRGB(0x00, 0x00, 0x00),
+					vterm.NewColorRGB(0xFF, 0x80, 0x00),
+					vterm.NewColorRGB(0xC4, 0xC4, 0xC4),
+				},
+			},
+			wantOK: true,
+		},
+		{
+			name:     "ColorsEmptyEntry",
+			in:       "000000,,FFFFFF",
+			wantSpec: PaletteSpec{},
+			wantOK:   false,
+		},
+		{
+			name:     "ColorsInvalidHex",
+			in:       "00000G",
+			wantSpec: PaletteSpec{},
+			wantOK:   false,
+		},
+		{
+			name:     "ColorsShort",
+			in:       "FFF",
+			wantSpec: PaletteSpec{},
+			wantOK:   false,
+		},
+		{
+			name:     "ColorsTooMany",
+			in:       "000000,000000,000000,000000,000000,000000,000000,000000,000000,000000,000000,000000,000000,000000,000000,000000,000000",
+			wantSpec: PaletteSpec{},
+			wantOK:   false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			gotSpec, gotOK := ParsePaletteSpec(tc.in)
+			if !reflect.DeepEqual(gotSpec, tc.wantSpec) {
+				t.Errorf("spec: expected %#v, got %#v", tc.wantSpec, gotSpec)
+			}
+			if gotOK != tc.wantOK {
+				t.Errorf("ok: expected %t, got %t", tc.wantOK, gotOK)
+			}
+		})
+	}
+}
+
+func TestNearestColor(t *testing.T) {
+	target := []vterm.Color{
+		vterm.NewColorRGB(0x00, 0x00, 0x00),
+		vterm.NewColorRGB(0xFF, 0x00, 0x00),
+		vterm.NewColorRGB(0x00, 0xFF, 0x00),
+		vterm.NewColorRGB(0x00, 0x00, 0xFF),
+		vterm.NewColorRGB(0xFF, 0xFF, 0xFF),
+	}
+
+	tt := []struct {
+		name string
+		in   vterm.Color
+		want int
+	}{
+		{
+			name: "Exact",
+			in:   vterm.NewColorRGB(0x00, 0xFF, 0x00),
+			want: 2,
+		},
+		{
+			name: "DarkGray",
+			in:   vterm.NewColorRGB(0x30, 0x30, 0x30),
+			want: 0,
+		},
+		{
+			name: "LightGray",
+			in:   vterm.NewColorRGB(0xC4, 0xC4, 0xC4),
+			want: 4,
+		},
+		{
+			name: "Orange",
+			in:   vterm.NewColorRGB(0xE0, 0x40, 0x10),
+			want: 1,
+		},
+		{
+			name: "Navy",
+			in:   vterm.NewColorRGB(0x10, 0x10, 0xA0),
+			want: 3,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := NearestColor(tc.in, target)
+			if got != tc.want {
+				t.Errorf("expected %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	var pal Theme
+	pal.FG = vterm.NewColorRGB(0xC4, 0xC4, 0xC4)
+	pal.FG.Type |= vterm.ColorDefaultFG
+	pal.BG = vterm.NewColorRGB(0x10, 0x10, 0x10)
+	pal.BG.Type |= vterm.ColorDefaultBG
+	pal.Palette[1] = vterm.NewColorRGB(0xC4, 0x40, 0x40)
+	pal.Palette[9] = vterm.NewColorRGB(0xFF, 0x60, 0x60)
+
+	target := []vterm.Color{
+		vterm.NewColorRGB(0x00, 0x00, 0x00),
+		vterm.NewColorRGB(0xFF, 0x00, 0x00),
+		vterm.NewColorRGB(0xFF, 0xFF, 0xFF),
+	}
+
+	in := vterm.ScreenShot{
+		Stride: 3,
+		Cell: []vterm.Cell{
+			{FG: pal.FG, BG: pal.BG},
+			{FG: vterm.NewColorIndexed(1), BG: vterm.NewColorIndexed(9)},
+			{FG: vterm.NewColorRGB(0xF0, 0xF0, 0xF0), BG: vterm.NewColorRGB(0x20, 0x00, 0x00)},
+		},
+	}
+	want := vterm.ScreenShot{
+		Stride: 3,
+		Cell: []vterm.Cell{
+			{FG: vterm.NewColorIndexed(2), BG: vterm.NewColorIndexed(0)},
+			{FG: vterm.NewColorIndexed(1), BG: vterm.NewColorIndexed(1)},
+			{FG: vterm.NewColorIndexed(2), BG: vterm.NewColorIndexed(0)},
+		},
+	}
+
+	got := Quantize(ResolveColors(in, pal), target)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ss: expected %#v, got %#v", want, got)
+	}
+	if !reflect.DeepEqual(in.Cell[0].FG, pal.FG) {
+		t.Errorf("input modified")
+	}
+
+	wantTheme := Theme{FG: target[2], BG: target[0]}
+	copy(wantTheme.Palette[:], target)
+	gotTheme := QuantizeTheme(pal, target)
+	if !reflect.DeepEqual(gotTheme, wantTheme) {
+		t.Errorf("theme: expected %#v, got %#v", wantTheme, gotTheme)
+	}
+}
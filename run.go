@@ -2,42 +2,47 @@ package main
 
 import (
 	"context"
-	"io"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
+	"os"
 	"os/signal"
+	"strconv"
 )
 
-const logFlags = log.Ldate | log.Ltime | log.Lmsgprefix
-
 type MainConfig struct {
+	Addr       string
 	Port       int
+	Socket     string
+	SocketMode os.FileMode
 	TermConfig TermConfig
-	LogWriter  io.Writer
+	Macros     map[string]Macro
+	Auth       *Auth
+	Logger     *slog.Logger
 }
 
 func Run(cfg MainConfig) int {
-	logger := log.New(cfg.LogWriter, "main: ", logFlags)
+	logger := cfg.Logger
 
 	ctx, stop := signal.NotifyContext(context.Background(), signals...)
 	defer stop()
 
-	slot := NewTermSlot(cfg.TermConfig)
+	tcfg := cfg.TermConfig
+	tcfg.Logger = logger.With("service", "term")
+	slot := NewTermSlot(tcfg)
 	defer slot.Stop()
 
-	addr := &net.TCPAddr{
-		IP:   net.IPv4(127, 0, 0, 1),
-		Port: cfg.Port,
-	}
-	lis, err := net.ListenTCP("tcp", addr)
+	lis, err := listen(cfg)
 	if err != nil {
-		logger.Printf("error: %s", err.Error())
+		logger.Error("failed to listen", "err", err.Error())
 		return 1
 	}
-	logger.Printf("listening on %s", lis.Addr().(*net.TCPAddr).String())
+	logger.Info("listening", "addr", lis.Addr().String())
+	if addr, ok := lis.Addr().(*net.TCPAddr); ok && !addr.IP.IsLoopback() {
+		logger.Warn("not a loopback address; the terminal is exposed to the network", "ip", addr.IP.String())
+	}
 
-	server := BuildServer(slot, cfg.LogWriter)
+	server := BuildServer(slot, cfg.Macros, cfg.Auth, logger)
 	serverDone := make(chan error)
 	go func() {
 		err := server.Serve(lis)
@@ -51,43 +56,236 @@ func Run(cfg MainConfig) int {
 	code := 0
 	err = server.Shutdown(context.Background())
 	if err != nil {
-		logger.Printf("error: %s", err.Error())
+		logger.Error("failed to shut down", "err", err.Error())
 		code = 1
 	}
 	err = <-serverDone
 	if err != http.ErrServerClosed {
-		logger.Printf("error: %s", err.Error())
+		logger.Error("server failed", "err", err.Error())
 		code = 1
 	}
 
 	return code
 }
 
-func BuildServeMux(slot *TermSlot, logw io.Writer) *http.ServeMux {
+func listen(cfg MainConfig) (net.Listener, error) {
+	if cfg.Socket != "" {
+		return listenUnix(cfg.Socket, cfg.SocketMode)
+	}
+
+	addr := net.JoinHostPort(cfg.Addr, strconv.Itoa(cfg.Port))
+	return net.Listen("tcp", addr)
+}
+
+func BuildServeMux(slot *TermSlot, macros map[string]Macro, auth *Auth, logger *slog.Logger) *http.ServeMux {
+	metrics := NewMetrics()
 	mux := http.NewServeMux()
+	mux.Handle("/clipboard", &ServiceHandler{
+		Service: &ClipboardService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "clipboard"),
+		},
+		Name:       "clipboard",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionControl,
+	})
+	mux.Handle("/copy", &ServiceHandler{
+		Service: &CopyService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "copy"),
+		},
+		Name:       "copy",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionControl,
+	})
+	mux.Handle("/events", &ServiceHandler{
+		Service: &EventService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "events"),
+		},
+		Name:       "events",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionView,
+	})
+	mux.Handle("/healthz", &ServiceHandler{
+		Service:    &HealthService{},
+		Name:       "healthz",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionNone,
+	})
 	mux.Handle("/keyboard", &ServiceHandler{
 		Service: &KeyboardService{
 			TermSlot: slot,
-			Logger:   log.New(logw, "keyboard: ", logFlags),
+			Logger:   logger.With("service", "keyboard"),
+			Metrics:  metrics,
+		},
+		Name:       "keyboard",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionControl,
+	})
+	mux.Handle("/macro", &ServiceHandler{
+		Service: &MacroService{
+			TermSlot: slot,
+			Macros:   macros,
+			Logger:   logger.With("service", "macro"),
+		},
+		Name:       "macro",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionControl,
+	})
+	mux.Handle("/metrics", &ServiceHandler{
+		Service: &MetricsService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "metrics"),
+			Metrics:  metrics,
+		},
+		Name:       "metrics",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionView,
+	})
+	mux.Handle("/osc", &ServiceHandler{
+		Service: &SequenceService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "osc"),
 		},
+		Name:       "osc",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionControl,
+	})
+	mux.Handle("/readyz", &ServiceHandler{
+		Service: &ReadyService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "readyz"),
+		},
+		Name:       "readyz",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionNone,
 	})
 	mux.Handle("/screen", &ServiceHandler{
 		Service: &ScreenService{
 			TermSlot: slot,
-			Logger:   log.New(logw, "screen: ", logFlags),
+			Logger:   logger.With("service", "screen"),
+			Metrics:  metrics,
+		},
+		Name:       "screen",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionView,
+		ViewService: &ScreenService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "screen"),
+			Metrics:  metrics,
+			NoStart:  true,
+		},
+	})
+	mux.Handle("/screen.json", &ServiceHandler{
+		Service: &ScreenJSONService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "screen.json"),
+			Metrics:  metrics,
+		},
+		Name:       "screen.json",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionView,
+		ViewService: &ScreenJSONService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "screen.json"),
+			Metrics:  metrics,
+			NoStart:  true,
+		},
+	})
+	mux.Handle("/signal", &ServiceHandler{
+		Service: &SignalService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "signal"),
+		},
+		Name:       "signal",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionControl,
+	})
+	mux.Handle("/start", &ServiceHandler{
+		Service: &StartService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "start"),
+		},
+		Name:       "start",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionControl,
+	})
+	mux.Handle("/status", &ServiceHandler{
+		Service: &StatusService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "status"),
+		},
+		Name:       "status",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionView,
+	})
+	mux.Handle("/status.json", &ServiceHandler{
+		Service: &StatusJSONService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "status.json"),
+		},
+		Name:       "status.json",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionView,
+	})
+	mux.Handle("/theme", &ServiceHandler{
+		Service: &ThemeService{
+			TermSlot: slot,
+			Logger:   logger.With("service", "theme"),
 		},
+		Name:       "theme",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionControl,
 	})
 	mux.Handle("/stop", &ServiceHandler{
 		Service: &StopService{
 			TermSlot: slot,
 		},
+		Name:       "stop",
+		Metrics:    metrics,
+		Logger:     logger,
+		Auth:       auth,
+		Permission: PermissionControl,
 	})
 	return mux
 }
 
-func BuildServer(slot *TermSlot, logw io.Writer) *http.Server {
+func BuildServer(slot *TermSlot, macros map[string]Macro, auth *Auth, logger *slog.Logger) *http.Server {
 	return &http.Server{
-		Handler:  BuildServeMux(slot, logw),
-		ErrorLog: log.New(logw, "server: error: ", logFlags),
+		Handler:  BuildServeMux(slot, macros, auth, logger),
+		ErrorLog: slog.NewLogLogger(logger.With("service", "server").Handler(), slog.LevelError),
 	}
 }
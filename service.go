@@ -1,27 +1,51 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/gcrtnst/sw-term-server/internal/vterm"
+	"github.com/gcrtnst/sw-term-server/internal/xpty"
 )
 
+const DefaultMaxBodySize = 64 << 10
+
 type ServiceHandler struct {
-	Service Service
+	Service     Service
+	MaxBodySize int64
+
+	// Name labels the requests counted in Metrics and written to Logger.
+	Name    string
+	Metrics *Metrics
+	Logger  *slog.Logger
+
+	// Auth checks the "token" parameter against Permission before the
+	// request reaches Service.
+	Auth       *Auth
+	Permission Permission
+
+	// ViewService, if set, serves the requests whose token grants no more
+	// than PermissionView, instead of Service.
+	ViewService Service
 }
 
 func (h *ServiceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" && r.Method != "" {
+	start := time.Now()
+	if r.Method != "GET" && r.Method != "POST" && r.Method != "" {
 		resp := &ServiceResponse{
 			Code: http.StatusMethodNotAllowed,
 			Body: []byte("method not allowed"),
 		}
-		_ = resp.WriteResponse(w)
+		h.writeResponse(w, r, nil, start, resp)
 		return
 	}
 
@@ -31,12 +55,180 @@ func (h *ServiceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			Code: http.StatusBadRequest,
 			Body: []byte("invalid url query"),
 		}
-		_ = resp.WriteResponse(w)
+		h.writeResponse(w, r, nil, start, resp)
 		return
 	}
 
-	resp := h.Service.ServeAPI(query)
+	if r.Method == "POST" {
+		form, resp := h.parseBody(w, r)
+		if resp != nil {
+			h.writeResponse(w, r, query, start, resp)
+			return
+		}
+
+		// As with http.Request.Form, body values take precedence over
+		// URL query values.
+		for k, vs := range query {
+			form[k] = append(form[k], vs...)
+		}
+		query = form
+	}
+
+	if resp := h.Auth.Check(query.Get("token"), h.Permission); resp != nil {
+		h.writeResponse(w, r, query, start, resp)
+		return
+	}
+
+	params := url.Values{}
+	for k, vs := range query {
+		if k != "token" {
+			params[k] = vs
+		}
+	}
+
+	srv := h.Service
+	if h.ViewService != nil && h.Auth.Grant(query.Get("token")) <= PermissionView {
+		srv = h.ViewService
+	}
+	resp := srv.ServeAPI(params)
+	h.writeResponse(w, r, query, start, resp)
+}
+
+func (h *ServiceHandler) writeResponse(w http.ResponseWriter, r *http.Request, query url.Values, start time.Time, resp *ServiceResponse) {
+	h.Metrics.ObserveRequest(h.Name, resp.Code)
 	_ = resp.WriteResponse(w)
+
+	// Successful requests are logged at debug level, as the client polls
+	// the screen several times per second.
+	if h.Logger != nil {
+		level := slog.LevelDebug
+		if resp.Code >= 400 {
+			level = slog.LevelInfo
+		}
+		h.Logger.Log(r.Context(), level, "request",
+			"service", h.Name,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"params", redactQuery(query),
+			"status", resp.Code,
+			"duration", time.Since(start),
+		)
+	}
+}
+
+func (h *ServiceHandler) parseBody(w http.ResponseWriter, r *http.Request) (url.Values, *ServiceResponse) {
+	limit := h.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	var errMaxBytes *http.MaxBytesError
+	if errors.As(err, &errMaxBytes) {
+		return nil, &ServiceResponse{
+			Code: http.StatusRequestEntityTooLarge,
+			Body: []byte("request body too large"),
+		}
+	}
+	if err != nil {
+		return nil, &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte("failed to read request body"),
+		}
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" && len(body) <= 0 {
+		return url.Values{}, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, &ServiceResponse{
+			Code: http.StatusUnsupportedMediaType,
+			Body: []byte("unsupported media type"),
+		}
+	}
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte("invalid form body"),
+			}
+		}
+		return form, nil
+
+	case "application/json":
+		form, err := parseJSONForm(body)
+		if err != nil {
+			return nil, &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte("invalid json body"),
+			}
+		}
+		return form, nil
+
+	default:
+		return nil, &ServiceResponse{
+			Code: http.StatusUnsupportedMediaType,
+			Body: []byte("unsupported media type"),
+		}
+	}
+}
+
+// parseJSONForm converts a flat JSON object into url.Values, so that services
+// can handle JSON bodies in the same way as URL queries. Each member must be a
+// string, number, boolean or an array of them.
+func parseJSONForm(b []byte) (url.Values, error) {
+	var obj map[string]json.RawMessage
+	err := json.Unmarshal(b, &obj)
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, errors.New("json body is not an object")
+	}
+
+	form := url.Values{}
+	for k, raw := range obj {
+		var arr []json.RawMessage
+		if json.Unmarshal(raw, &arr) != nil {
+			arr = []json.RawMessage{raw}
+		}
+
+		for _, elem := range arr {
+			v, err := parseJSONScalar(elem)
+			if err != nil {
+				return nil, err
+			}
+			form.Add(k, v)
+		}
+	}
+	return form, nil
+}
+
+func parseJSONScalar(raw json.RawMessage) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var v any
+	err := dec.Decode(&v)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("unsupported json value %s", string(raw))
+	}
 }
 
 type Service interface {
@@ -45,7 +237,8 @@ type Service interface {
 
 type KeyboardService struct {
 	TermSlot *TermSlot
-	Logger   *log.Logger
+	Logger   *slog.Logger
+	Metrics  *Metrics
 }
 
 func (srv *KeyboardService) ServeAPI(query url.Values) *ServiceResponse {
@@ -81,39 +274,193 @@ func (srv *KeyboardService) ServeAPI(query url.Values) *ServiceResponse {
 			Body: []byte(s),
 		}
 	}
+	if errors.Is(err, ErrInputDropped) {
+		s := err.Error()
+		return &ServiceResponse{
+			Code: http.StatusServiceUnavailable,
+			Body: []byte(s),
+		}
+	}
 	if err != nil {
-		srv.Logger.Printf("error: %s", err.Error())
+		srv.Logger.Error("internal error", "err", err.Error())
 		return &ServiceResponse{
 			Code: http.StatusInternalServerError,
 			Body: []byte("internal server error"),
 		}
 	}
 
+	srv.Metrics.AddKeyboardEvent()
 	return &ServiceResponse{
 		Code: http.StatusOK,
 		Body: []byte{},
 	}
 }
 
-type ScreenService struct {
+type MacroService struct {
 	TermSlot *TermSlot
-	Logger   *log.Logger
+	Macros   map[string]Macro
+	Logger   *slog.Logger
 }
 
-func (srv *ScreenService) ServeAPI(query url.Values) *ServiceResponse {
-	ss, err := srv.TermSlot.CaptureRGB()
+func (srv *MacroService) ServeAPI(query url.Values) *ServiceResponse {
+	queryName := query.Get("name")
+	if queryName == "" {
+		return &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte(`missing parameter "name"`),
+		}
+	}
+
+	m, ok := srv.Macros[queryName]
+	if !ok {
+		s := fmt.Sprintf(`invalid parameter "name": %q`, queryName)
+		return &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte(s),
+		}
+	}
+
+	err := srv.TermSlot.Macro(m)
+	if errors.Is(err, ErrInputDropped) {
+		s := err.Error()
+		return &ServiceResponse{
+			Code: http.StatusServiceUnavailable,
+			Body: []byte(s),
+		}
+	}
 	if err != nil {
-		srv.Logger.Printf("error: %s", err.Error())
+		srv.Logger.Error("internal error", "err", err.Error())
 		return &ServiceResponse{
 			Code: http.StatusInternalServerError,
 			Body: []byte("internal server error"),
 		}
 	}
 
+	return &ServiceResponse{
+		Code: http.StatusOK,
+		Body: []byte{},
+	}
+}
+
+type ScreenService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+	Metrics  *Metrics
+	Now      func() time.Time
+
+	// NoStart makes the service fail with 409 instead of starting a
+	// terminal that is not running.
+	NoStart bool
+}
+
+func (srv *ScreenService) ServeAPI(query url.Values) *ServiceResponse {
+	queryColor := query.Get("color")
+	if queryColor != "" && queryColor != "rgb" && queryColor != "indexed" {
+		s := fmt.Sprintf(`invalid parameter "color": %q`, queryColor)
+		return &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte(s),
+		}
+	}
+
+	var spec PaletteSpec
+	queryPalette := query.Get("palette")
+	if queryPalette != "" {
+		var ok bool
+		spec, ok = ParsePaletteSpec(queryPalette)
+		if !ok {
+			s := fmt.Sprintf(`invalid parameter "palette": %q`, queryPalette)
+			return &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(s),
+			}
+		}
+	}
+
+	flatten := false
+	queryFlatten := query.Get("flatten")
+	if queryFlatten != "" {
+		var err error
+		flatten, err = strconv.ParseBool(queryFlatten)
+		if err != nil {
+			s := fmt.Sprintf(`invalid parameter "flatten": %q`, queryFlatten)
+			return &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(s),
+			}
+		}
+	}
+
+	now := time.Now
+	if srv.Now != nil {
+		now = srv.Now
+	}
+
+	start := time.Now()
 	var b []byte
-	b = EncodeScreenShot(ss)
+	var sig string
+	if queryColor == "indexed" || queryPalette != "" || flatten {
+		ss, pal, err := srv.TermSlot.CaptureIndexed(!srv.NoStart)
+		if errors.Is(err, ErrNotRunning) {
+			return &ServiceResponse{
+				Code: http.StatusConflict,
+				Body: []byte(err.Error()),
+			}
+		}
+		if err != nil {
+			srv.Logger.Error("internal error", "err", err.Error())
+			return &ServiceResponse{
+				Code: http.StatusInternalServerError,
+				Body: []byte("internal server error"),
+			}
+		}
+
+		if flatten {
+			ss = Flatten(ss, BlinkPhase(now()))
+		}
+		if queryPalette != "" {
+			target := spec.Resolve(pal)
+			ss = Quantize(ResolveColors(ss, pal), target)
+			pal = QuantizeTheme(pal, target)
+		}
+
+		switch {
+		case queryColor == "indexed" && flatten:
+			b = EncodeScreenShotIndexedFlat(ss, pal)
+			sig = "%SWTSCRJ"
+		case queryColor == "indexed":
+			b = EncodeScreenShotIndexed(ss, pal)
+			sig = "%SWTSCRI"
+		case flatten:
+			b = EncodeScreenShotFlat(ResolveColors(ss, pal))
+			sig = "%SWTSCRF"
+		default:
+			b = EncodeScreenShot(ResolveColors(ss, pal))
+			sig = "%SWTSCRN"
+		}
+	} else {
+		ss, err := srv.TermSlot.CaptureRGB(!srv.NoStart)
+		if errors.Is(err, ErrNotRunning) {
+			return &ServiceResponse{
+				Code: http.StatusConflict,
+				Body: []byte(err.Error()),
+			}
+		}
+		if err != nil {
+			srv.Logger.Error("internal error", "err", err.Error())
+			return &ServiceResponse{
+				Code: http.StatusInternalServerError,
+				Body: []byte("internal server error"),
+			}
+		}
+		b = EncodeScreenShot(ss)
+		sig = "%SWTSCRN"
+	}
+
 	b = EscapeZero(b)
-	b = append([]byte("%SWTSCRN"), b...)
+	b = append([]byte(sig), b...)
+	srv.Metrics.ObserveScreenLatency(time.Since(start))
+	srv.Metrics.AddScreenBytes(len(b))
 
 	return &ServiceResponse{
 		Code: http.StatusOK,
@@ -121,6 +468,538 @@ func (srv *ScreenService) ServeAPI(query url.Values) *ServiceResponse {
 	}
 }
 
+var signalNames = map[string]xpty.Signal{
+	"HUP":  xpty.SignalHUP,
+	"INT":  xpty.SignalINT,
+	"KILL": xpty.SignalKILL,
+	"TERM": xpty.SignalTERM,
+	"TSTP": xpty.SignalTSTP,
+}
+
+var signalTargetNames = map[string]xpty.SignalTarget{
+	"foreground": xpty.TargetForeground,
+	"session":    xpty.TargetSession,
+}
+
+type SignalService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+}
+
+func (srv *SignalService) ServeAPI(query url.Values) *ServiceResponse {
+	queryName := query.Get("name")
+	if queryName == "" {
+		return &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte(`missing parameter "name"`),
+		}
+	}
+	sig, ok := signalNames[queryName]
+	if !ok {
+		s := fmt.Sprintf(`invalid parameter "name": %q`, queryName)
+		return &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte(s),
+		}
+	}
+
+	target := xpty.DefaultSignalTarget(sig)
+	queryTarget := query.Get("target")
+	if queryTarget != "" {
+		target, ok = signalTargetNames[queryTarget]
+		if !ok {
+			s := fmt.Sprintf(`invalid parameter "target": %q`, queryTarget)
+			return &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(s),
+			}
+		}
+	}
+
+	err := srv.TermSlot.Signal(sig, target)
+	if errors.Is(err, ErrNotRunning) || errors.Is(err, xpty.ErrNoForeground) || errors.Is(err, xpty.ErrNoProcess) {
+		s := err.Error()
+		return &ServiceResponse{
+			Code: http.StatusConflict,
+			Body: []byte(s),
+		}
+	}
+	if errors.Is(err, xpty.ErrUnsupported) {
+		s := err.Error()
+		return &ServiceResponse{
+			Code: http.StatusNotImplemented,
+			Body: []byte(s),
+		}
+	}
+	if errors.Is(err, ErrInputDropped) {
+		s := err.Error()
+		return &ServiceResponse{
+			Code: http.StatusServiceUnavailable,
+			Body: []byte(s),
+		}
+	}
+	if err != nil {
+		srv.Logger.Error("internal error", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusInternalServerError,
+			Body: []byte("internal server error"),
+		}
+	}
+
+	return &ServiceResponse{
+		Code: http.StatusOK,
+		Body: []byte{},
+	}
+}
+
+type StatusService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+}
+
+func (srv *StatusService) ServeAPI(query url.Values) *ServiceResponse {
+	st, err := srv.TermSlot.Status()
+	if err != nil {
+		srv.Logger.Error("internal error", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusInternalServerError,
+			Body: []byte("internal server error"),
+		}
+	}
+
+	v := url.Values{}
+	v.Set("running", strconv.FormatBool(st.Running))
+	if st.Running || st.Exited {
+		v.Set("row", strconv.Itoa(st.Row))
+		v.Set("col", strconv.Itoa(st.Col))
+	}
+	if st.Exited {
+		v.Set("exit_code", strconv.Itoa(st.ExitCode))
+	}
+	if st.Foreground.PID > 0 {
+		v.Set("pid", strconv.Itoa(st.Foreground.PID))
+		v.Set("command", st.Foreground.Name)
+		v.Set("idle", strconv.FormatBool(st.Idle))
+	}
+
+	return &ServiceResponse{
+		Code: http.StatusOK,
+		Body: []byte(v.Encode()),
+	}
+}
+
+type StatusJSONService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+	Now      func() time.Time
+}
+
+func (srv *StatusJSONService) ServeAPI(query url.Values) *ServiceResponse {
+	st, err := srv.TermSlot.Status()
+	if err != nil {
+		srv.Logger.Error("internal error", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusInternalServerError,
+			Body: []byte("internal server error"),
+		}
+	}
+
+	now := time.Now
+	if srv.Now != nil {
+		now = srv.Now
+	}
+
+	return &ServiceResponse{
+		Code:        http.StatusOK,
+		ContentType: "application/json",
+		Body:        EncodeStatusJSON(st, now()),
+	}
+}
+
+type ScreenJSONService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+	Metrics  *Metrics
+
+	// NoStart makes the service fail with 409 instead of starting a
+	// terminal that is not running.
+	NoStart bool
+}
+
+func (srv *ScreenJSONService) ServeAPI(query url.Values) *ServiceResponse {
+	ss, err := srv.TermSlot.CaptureRGB(!srv.NoStart)
+	if errors.Is(err, ErrNotRunning) {
+		return &ServiceResponse{
+			Code: http.StatusConflict,
+			Body: []byte(err.Error()),
+		}
+	}
+	if err != nil {
+		srv.Logger.Error("internal error", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusInternalServerError,
+			Body: []byte("internal server error"),
+		}
+	}
+
+	b := EncodeScreenShotJSON(ss)
+	srv.Metrics.AddScreenBytes(len(b))
+
+	return &ServiceResponse{
+		Code:        http.StatusOK,
+		ContentType: "application/json",
+		Body:        b,
+	}
+}
+
+type ClipboardService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+}
+
+func (srv *ClipboardService) ServeAPI(query url.Values) *ServiceResponse {
+	if query.Has("data") {
+		err := srv.TermSlot.SetClipboard([]byte(query.Get("data")))
+		if errors.Is(err, ErrNotRunning) {
+			s := err.Error()
+			return &ServiceResponse{
+				Code: http.StatusConflict,
+				Body: []byte(s),
+			}
+		}
+		if err != nil {
+			srv.Logger.Error("internal error", "err", err.Error())
+			return &ServiceResponse{
+				Code: http.StatusInternalServerError,
+				Body: []byte("internal server error"),
+			}
+		}
+
+		return &ServiceResponse{
+			Code: http.StatusOK,
+			Body: []byte{},
+		}
+	}
+
+	b, err := srv.TermSlot.Clipboard()
+	if err != nil {
+		srv.Logger.Error("internal error", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusInternalServerError,
+			Body: []byte("internal server error"),
+		}
+	}
+
+	return &ServiceResponse{
+		Code: http.StatusOK,
+		Body: b,
+	}
+}
+
+type CopyService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+}
+
+func (srv *CopyService) ServeAPI(query url.Values) *ServiceResponse {
+	var rect vterm.Rect
+	var resp *ServiceResponse
+	if rect.StartRow, resp = parseIntParam(query, "start_row", 0); resp != nil {
+		return resp
+	}
+	if rect.EndRow, resp = parseIntParam(query, "end_row", vterm.MaxInt); resp != nil {
+		return resp
+	}
+	if rect.StartCol, resp = parseIntParam(query, "start_col", 0); resp != nil {
+		return resp
+	}
+	if rect.EndCol, resp = parseIntParam(query, "end_col", vterm.MaxInt); resp != nil {
+		return resp
+	}
+
+	text, err := srv.TermSlot.Copy(rect)
+	if errors.Is(err, ErrNotRunning) {
+		s := err.Error()
+		return &ServiceResponse{
+			Code: http.StatusConflict,
+			Body: []byte(s),
+		}
+	}
+	if err != nil {
+		srv.Logger.Error("internal error", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusInternalServerError,
+			Body: []byte("internal server error"),
+		}
+	}
+
+	return &ServiceResponse{
+		Code: http.StatusOK,
+		Body: []byte(text),
+	}
+}
+
+func parseIntParam(query url.Values, name string, def int) (int, *ServiceResponse) {
+	v := query.Get(name)
+	if v == "" {
+		return def, nil
+	}
+
+	n, err := strconv.ParseUint(v, 10, 31)
+	if err != nil {
+		s := fmt.Sprintf(`failed to parse parameter %q: %s`, name, err.Error())
+		return 0, &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte(s),
+		}
+	}
+	return int(n), nil
+}
+
+type SequenceService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+}
+
+// ServeAPI returns the queued OSC and DCS sequences, one per line, each
+// encoded as a URL query string.
+func (srv *SequenceService) ServeAPI(query url.Values) *ServiceResponse {
+	seqs, err := srv.TermSlot.TakeSequences()
+	if err != nil {
+		srv.Logger.Error("internal error", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusInternalServerError,
+			Body: []byte("internal server error"),
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for _, seq := range seqs {
+		v := url.Values{}
+		switch seq.Kind {
+		case vterm.SequenceOSC:
+			v.Set("kind", "osc")
+			v.Set("command", strconv.Itoa(seq.Command))
+		case vterm.SequenceDCS:
+			v.Set("kind", "dcs")
+			v.Set("command", seq.DCSCommand)
+		}
+		v.Set("data", string(seq.Data))
+		_, _ = buf.WriteString(v.Encode())
+		_ = buf.WriteByte('\n')
+	}
+
+	return &ServiceResponse{
+		Code: http.StatusOK,
+		Body: buf.Bytes(),
+	}
+}
+
+type EventService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+}
+
+// ServeAPI returns the events newer than the "since" sequence number. The
+// first line holds the latest sequence number, and each following line is
+// an event encoded as a URL query string.
+func (srv *EventService) ServeAPI(query url.Values) *ServiceResponse {
+	var since uint64
+	querySince := query.Get("since")
+	if querySince != "" {
+		n, err := strconv.ParseUint(querySince, 10, 64)
+		if err != nil {
+			s := fmt.Sprintf(`failed to parse parameter "since": %s`, err.Error())
+			return &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(s),
+			}
+		}
+
+		since = n
+	}
+
+	events, last, err := srv.TermSlot.Events(since)
+	if err != nil {
+		srv.Logger.Error("internal error", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusInternalServerError,
+			Body: []byte("internal server error"),
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	_, _ = fmt.Fprintf(buf, "seq=%d\n", last)
+	for _, ev := range events {
+		v := url.Values{}
+		v.Set("seq", strconv.FormatUint(ev.Seq, 10))
+		v.Set("type", ev.Type)
+		v.Set("data", ev.Data)
+		_, _ = buf.WriteString(v.Encode())
+		_ = buf.WriteByte('\n')
+	}
+
+	return &ServiceResponse{
+		Code: http.StatusOK,
+		Body: buf.Bytes(),
+	}
+}
+
+type MetricsService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+	Metrics  *Metrics
+}
+
+func (srv *MetricsService) ServeAPI(query url.Values) *ServiceResponse {
+	buf := new(bytes.Buffer)
+	err := srv.Metrics.WriteText(buf, srv.TermSlot.Stats())
+	if err != nil {
+		srv.Logger.Error("internal error", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusInternalServerError,
+			Body: []byte("internal server error"),
+		}
+	}
+
+	return &ServiceResponse{
+		Code:        http.StatusOK,
+		ContentType: "text/plain; version=0.0.4",
+		Body:        buf.Bytes(),
+	}
+}
+
+type ThemeService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+}
+
+func (srv *ThemeService) ServeAPI(query url.Values) *ServiceResponse {
+	queryName := query.Get("name")
+	if queryName == "" {
+		return &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte(`missing parameter "name"`),
+		}
+	}
+
+	err := srv.TermSlot.SetTheme(queryName)
+	if errors.Is(err, ErrInvalidTheme) {
+		s := fmt.Sprintf(`invalid parameter "name": %q`, queryName)
+		return &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte(s),
+		}
+	}
+	if err != nil {
+		srv.Logger.Error("internal error", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusInternalServerError,
+			Body: []byte("internal server error"),
+		}
+	}
+
+	return &ServiceResponse{
+		Code: http.StatusOK,
+		Body: []byte{},
+	}
+}
+
+type HealthService struct{}
+
+func (srv *HealthService) ServeAPI(query url.Values) *ServiceResponse {
+	return &ServiceResponse{
+		Code: http.StatusOK,
+		Body: []byte("ok"),
+	}
+}
+
+type ReadyService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+}
+
+// ServeAPI reports whether a terminal can be started. Unlike most services,
+// it never starts the terminal itself.
+func (srv *ReadyService) ServeAPI(query url.Values) *ServiceResponse {
+	err := srv.TermSlot.Ready()
+	if err != nil {
+		srv.Logger.Warn("not ready", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusServiceUnavailable,
+			Body: []byte("not ready"),
+		}
+	}
+
+	return &ServiceResponse{
+		Code: http.StatusOK,
+		Body: []byte("ok"),
+	}
+}
+
+type StartService struct {
+	TermSlot *TermSlot
+	Logger   *slog.Logger
+}
+
+func (srv *StartService) ServeAPI(query url.Values) *ServiceResponse {
+	profile := query.Get("profile")
+	if profile == "" {
+		profile = DefaultProfileName
+	}
+
+	var row, col int
+	var resp *ServiceResponse
+	if row, resp = parseIntParam(query, "row", 0); resp != nil {
+		return resp
+	}
+	if col, resp = parseIntParam(query, "col", 0); resp != nil {
+		return resp
+	}
+	if row > MaxTermSize {
+		s := fmt.Sprintf(`invalid parameter "row": %q`, query.Get("row"))
+		return &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte(s),
+		}
+	}
+	if col > MaxTermSize {
+		s := fmt.Sprintf(`invalid parameter "col": %q`, query.Get("col"))
+		return &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte(s),
+		}
+	}
+
+	err := srv.TermSlot.Start(profile, row, col)
+	if errors.Is(err, ErrUnknownProfile) {
+		s := fmt.Sprintf(`invalid parameter "profile": %q`, profile)
+		return &ServiceResponse{
+			Code: http.StatusBadRequest,
+			Body: []byte(s),
+		}
+	}
+	if errors.Is(err, ErrAlreadyRunning) {
+		s := err.Error()
+		return &ServiceResponse{
+			Code: http.StatusConflict,
+			Body: []byte(s),
+		}
+	}
+	if err != nil {
+		srv.Logger.Error("internal error", "err", err.Error())
+		return &ServiceResponse{
+			Code: http.StatusInternalServerError,
+			Body: []byte("internal server error"),
+		}
+	}
+
+	return &ServiceResponse{
+		Code: http.StatusOK,
+		Body: []byte{},
+	}
+}
+
 type StopService struct {
 	TermSlot *TermSlot
 }
@@ -134,12 +1013,18 @@ func (srv *StopService) ServeAPI(query url.Values) *ServiceResponse {
 }
 
 type ServiceResponse struct {
-	Code int
-	Body []byte
+	Code        int
+	ContentType string
+	Body        []byte
 }
 
 func (r *ServiceResponse) WriteResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	contentType := r.ContentType
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.Header().Set("Content-Length", strconv.Itoa(len(r.Body)))
 	w.WriteHeader(r.Code)
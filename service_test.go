@@ -5,15 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/gcrtnst/sw-term-server/internal/vterm"
 	"github.com/gcrtnst/sw-term-server/internal/xpty"
 )
 
@@ -45,9 +47,149 @@ func TestServiceHandlerServeHTTP(t *testing.T) {
 			},
 			wantRespBody: []byte("test body"),
 		},
+		{
+			name:  "ContentType",
+			inReq: httptest.NewRequest("GET", "/path/to/api", nil),
+			inResp: &ServiceResponse{
+				Code:        http.StatusOK,
+				ContentType: "application/json",
+				Body:        []byte("{}"),
+			},
+			wantSvcQuery: url.Values{},
+			wantRespCode: http.StatusOK,
+			wantRespHeader: http.Header{
+				"Content-Type":           []string{"application/json"},
+				"X-Content-Type-Options": []string{"nosniff"},
+				"Content-Length":         []string{"2"},
+			},
+			wantRespBody: []byte("{}"),
+		},
+		{
+			name:  "PostForm",
+			inReq: newPostRequest("/path/to/api?key=query&q=1", "application/x-www-form-urlencoded", "key=body&b=2"),
+			inResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("test body"),
+			},
+			wantSvcQuery: url.Values{
+				"key": []string{"body", "query"},
+				"q":   []string{"1"},
+				"b":   []string{"2"},
+			},
+			wantRespCode: http.StatusOK,
+			wantRespHeader: http.Header{
+				"Content-Type":           []string{"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": []string{"nosniff"},
+				"Content-Length":         []string{"9"},
+			},
+			wantRespBody: []byte("test body"),
+		},
+		{
+			name:  "PostJSON",
+			inReq: newPostRequest("/path/to/api", "application/json; charset=utf-8", `{"key":"A","mod":6,"flag":true,"list":["x",1]}`),
+			inResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("test body"),
+			},
+			wantSvcQuery: url.Values{
+				"key":  []string{"A"},
+				"mod":  []string{"6"},
+				"flag": []string{"true"},
+				"list": []string{"x", "1"},
+			},
+			wantRespCode: http.StatusOK,
+			wantRespHeader: http.Header{
+				"Content-Type":           []string{"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": []string{"nosniff"},
+				"Content-Length":         []string{"9"},
+			},
+			wantRespBody: []byte("test body"),
+		},
+		{
+			name:  "PostEmpty",
+			inReq: newPostRequest("/path/to/api?key=value", "", ""),
+			inResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("test body"),
+			},
+			wantSvcQuery: url.Values{
+				"key": []string{"value"},
+			},
+			wantRespCode: http.StatusOK,
+			wantRespHeader: http.Header{
+				"Content-Type":           []string{"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": []string{"nosniff"},
+				"Content-Length":         []string{"9"},
+			},
+			wantRespBody: []byte("test body"),
+		},
+		{
+			name:  "PostInvalidForm",
+			inReq: newPostRequest("/path/to/api", "application/x-www-form-urlencoded", "key=value%"),
+			inResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("test body"),
+			},
+			wantSvcQuery: nil,
+			wantRespCode: http.StatusBadRequest,
+			wantRespHeader: http.Header{
+				"Content-Type":           []string{"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": []string{"nosniff"},
+				"Content-Length":         []string{"17"},
+			},
+			wantRespBody: []byte("invalid form body"),
+		},
+		{
+			name:  "PostInvalidJSON",
+			inReq: newPostRequest("/path/to/api", "application/json", `{"key":{"nested":1}}`),
+			inResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("test body"),
+			},
+			wantSvcQuery: nil,
+			wantRespCode: http.StatusBadRequest,
+			wantRespHeader: http.Header{
+				"Content-Type":           []string{"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": []string{"nosniff"},
+				"Content-Length":         []string{"17"},
+			},
+			wantRespBody: []byte("invalid json body"),
+		},
+		{
+			name:  "PostUnsupportedMediaType",
+			inReq: newPostRequest("/path/to/api", "text/plain", "key=value"),
+			inResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("test body"),
+			},
+			wantSvcQuery: nil,
+			wantRespCode: http.StatusUnsupportedMediaType,
+			wantRespHeader: http.Header{
+				"Content-Type":           []string{"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": []string{"nosniff"},
+				"Content-Length":         []string{"22"},
+			},
+			wantRespBody: []byte("unsupported media type"),
+		},
+		{
+			name:  "PostTooLarge",
+			inReq: newPostRequest("/path/to/api", "application/x-www-form-urlencoded", "key="+strings.Repeat("A", DefaultMaxBodySize)),
+			inResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("test body"),
+			},
+			wantSvcQuery: nil,
+			wantRespCode: http.StatusRequestEntityTooLarge,
+			wantRespHeader: http.Header{
+				"Content-Type":           []string{"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": []string{"nosniff"},
+				"Content-Length":         []string{"22"},
+			},
+			wantRespBody: []byte("request body too large"),
+		},
 		{
 			name:  "MethodNotAllowed",
-			inReq: httptest.NewRequest("POST", "/path/to/api?key=value", nil),
+			inReq: httptest.NewRequest("PUT", "/path/to/api?key=value", nil),
 			inResp: &ServiceResponse{
 				Code: http.StatusOK,
 				Body: []byte("test body"),
@@ -204,7 +346,7 @@ func TestKeyboardServiceServeAPI(t *testing.T) {
 				Code: http.StatusInternalServerError,
 				Body: []byte("internal server error"),
 			},
-			wantLog:   []byte("error: dummy error\n"),
+			wantLog:   []byte("level=ERROR msg=\"internal error\" err=\"dummy error\"\n"),
 			wantMTOut: []byte{},
 		},
 	}
@@ -228,7 +370,7 @@ func TestKeyboardServiceServeAPI(t *testing.T) {
 			slot := NewTermSlot(cfg)
 
 			logbuf := new(bytes.Buffer)
-			logger := log.New(logbuf, "", 0)
+			logger := newTestLogger(logbuf)
 
 			srv := &KeyboardService{
 				TermSlot: slot,
@@ -260,6 +402,138 @@ func TestKeyboardServiceServeAPI(t *testing.T) {
 	}
 }
 
+func TestMacroServiceServeAPI(t *testing.T) {
+	pid := os.Getpid()
+	macros := map[string]Macro{
+		"list":  {{Text: "ls"}, {Key: "Enter"}},
+		"empty": {},
+	}
+
+	tt := []struct {
+		name      string
+		inQuery   url.Values
+		inErrOpen error
+		wantResp  *ServiceResponse
+		wantLog   []byte
+		wantMTOut []byte
+	}{
+		{
+			name: "Normal",
+			inQuery: url.Values{
+				"name": []string{"list"},
+			},
+			inErrOpen: nil,
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+			wantLog:   []byte{},
+			wantMTOut: []byte("ls\r"),
+		},
+		{
+			name: "Empty",
+			inQuery: url.Values{
+				"name": []string{"empty"},
+			},
+			inErrOpen: nil,
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+			wantLog:   []byte{},
+			wantMTOut: []byte{},
+		},
+		{
+			name:      "MissingName",
+			inQuery:   url.Values{},
+			inErrOpen: nil,
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`missing parameter "name"`),
+			},
+			wantLog:   []byte{},
+			wantMTOut: []byte{},
+		},
+		{
+			name: "UnknownName",
+			inQuery: url.Values{
+				"name": []string{"unknown"},
+			},
+			inErrOpen: nil,
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`invalid parameter "name": "unknown"`),
+			},
+			wantLog:   []byte{},
+			wantMTOut: []byte{},
+		},
+		{
+			name: "ErrOpen",
+			inQuery: url.Values{
+				"name": []string{"list"},
+			},
+			inErrOpen: errors.New("dummy error"),
+			wantResp: &ServiceResponse{
+				Code: http.StatusInternalServerError,
+				Body: []byte("internal server error"),
+			},
+			wantLog:   []byte("level=ERROR msg=\"internal error\" err=\"dummy error\"\n"),
+			wantMTOut: []byte{},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{
+				ErrOpen: tc.inErrOpen,
+				PID:     pid,
+			}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+			}
+			slot := NewTermSlot(cfg)
+
+			logbuf := new(bytes.Buffer)
+			logger := newTestLogger(logbuf)
+
+			srv := &MacroService{
+				TermSlot: slot,
+				Macros:   macros,
+				Logger:   logger,
+			}
+
+			gotResp := srv.ServeAPI(tc.inQuery)
+			gotLog := logbuf.Bytes()
+
+			mt.ErrOpen = nil
+			slot.start()
+			slot.term.pc = nil
+			slot.Stop()
+			gotMTOut, _ := io.ReadAll(mt.Computer())
+
+			if gotResp.Code != tc.wantResp.Code {
+				t.Errorf("resp code: expected %d, got %d", tc.wantResp.Code, gotResp.Code)
+			}
+			if !bytes.Equal(gotResp.Body, tc.wantResp.Body) {
+				t.Errorf("resp body: expected %#v, got %#v", tc.wantResp.Body, gotResp.Body)
+			}
+			if !bytes.Equal(gotLog, tc.wantLog) {
+				t.Errorf("log: expected %#v, got %#v", tc.wantLog, gotLog)
+			}
+			if !bytes.Equal(gotMTOut, tc.wantMTOut) {
+				t.Errorf("mt out: expected %#v, got %#v", tc.wantMTOut, gotMTOut)
+			}
+		})
+	}
+}
+
 func TestScreenServiceServeAPI(t *testing.T) {
 	errDummy := errors.New("dummy error")
 	pid := os.Getpid()
@@ -267,9 +541,12 @@ func TestScreenServiceServeAPI(t *testing.T) {
 	tt := []struct {
 		name        string
 		inStart     bool
+		inNoStart   bool
+		inQuery     url.Values
 		inIn        []byte
 		inMTErrOpen error
 		wantResp    *ServiceResponse
+		wantRunning bool
 		wantLog     []byte
 	}{
 		{
@@ -378,7 +655,33 @@ func TestScreenServiceServeAPI(t *testing.T) {
 					0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, // len(string(Cell[i].Runes))
 				},
 			},
-			wantLog: []byte{},
+			wantRunning: true,
+			wantLog:     []byte{},
+		},
+		{
+			name:      "NoStart",
+			inStart:   false,
+			inNoStart: true,
+			wantResp: &ServiceResponse{
+				Code: http.StatusConflict,
+				Body: []byte("terminal not running"),
+			},
+			wantRunning: false,
+			wantLog:     []byte{},
+		},
+		{
+			name:      "NoStartIndexed",
+			inStart:   false,
+			inNoStart: true,
+			inQuery: url.Values{
+				"color": []string{"indexed"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusConflict,
+				Body: []byte("terminal not running"),
+			},
+			wantRunning: false,
+			wantLog:     []byte{},
 		},
 		{
 			name:        "ErrOpen",
@@ -388,7 +691,43 @@ func TestScreenServiceServeAPI(t *testing.T) {
 				Code: http.StatusInternalServerError,
 				Body: []byte("internal server error"),
 			},
-			wantLog: []byte("error: dummy error\n"),
+			wantLog: []byte("level=ERROR msg=\"internal error\" err=\"dummy error\"\n"),
+		},
+		{
+			name:    "InvalidColor",
+			inStart: false,
+			inQuery: url.Values{
+				"color": []string{"grayscale"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`invalid parameter "color": "grayscale"`),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:    "InvalidPalette",
+			inStart: false,
+			inQuery: url.Values{
+				"palette": []string{"12"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`invalid parameter "palette": "12"`),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:    "InvalidFlatten",
+			inStart: false,
+			inQuery: url.Values{
+				"flatten": []string{"yes"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`invalid parameter "flatten": "yes"`),
+			},
+			wantLog: []byte{},
 		},
 	}
 
@@ -428,15 +767,17 @@ func TestScreenServiceServeAPI(t *testing.T) {
 			}
 
 			logbuf := new(bytes.Buffer)
-			logger := log.New(logbuf, "", 0)
+			logger := newTestLogger(logbuf)
 
 			srv := &ScreenService{
 				TermSlot: slot,
 				Logger:   logger,
+				NoStart:  tc.inNoStart,
 			}
 
-			gotResp := srv.ServeAPI(nil)
+			gotResp := srv.ServeAPI(tc.inQuery)
 			gotLog := logbuf.Bytes()
+			gotRunning := slot.term != nil
 
 			if slot.term != nil {
 				slot.term.pc = nil
@@ -449,6 +790,9 @@ func TestScreenServiceServeAPI(t *testing.T) {
 			if !bytes.Equal(gotResp.Body, tc.wantResp.Body) {
 				t.Errorf("resp body: expected %#v, got %#v", string(tc.wantResp.Body), string(gotResp.Body))
 			}
+			if gotRunning != tc.wantRunning {
+				t.Errorf("running: expected %t, got %t", tc.wantRunning, gotRunning)
+			}
 			if !bytes.Equal(gotLog, tc.wantLog) {
 				t.Errorf("log: expected %#v, got %#v", string(tc.wantLog), string(gotLog))
 			}
@@ -456,12 +800,1364 @@ func TestScreenServiceServeAPI(t *testing.T) {
 	}
 }
 
-type MockService struct {
-	Query url.Values
-	Resp  *ServiceResponse
+func TestSignalServiceServeAPI(t *testing.T) {
+	errDummy := errors.New("dummy error")
+	pid := os.Getpid()
+
+	tt := []struct {
+		name             string
+		inStart          bool
+		inQuery          url.Values
+		inErrSignal      error
+		wantResp         *ServiceResponse
+		wantLog          []byte
+		wantSignal       xpty.Signal
+		wantSignalTarget xpty.SignalTarget
+		wantMTOut        []byte
+	}{
+		{
+			name:    "Normal",
+			inStart: true,
+			inQuery: url.Values{
+				"name": []string{"INT"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+			wantLog:          []byte{},
+			wantSignal:       xpty.SignalINT,
+			wantSignalTarget: xpty.TargetForeground,
+		},
+		{
+			name:    "Session",
+			inStart: true,
+			inQuery: url.Values{
+				"name":   []string{"KILL"},
+				"target": []string{"session"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+			wantLog:          []byte{},
+			wantSignal:       xpty.SignalKILL,
+			wantSignalTarget: xpty.TargetSession,
+		},
+		{
+			name:    "MissingName",
+			inStart: true,
+			inQuery: url.Values{},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`missing parameter "name"`),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:    "InvalidName",
+			inStart: true,
+			inQuery: url.Values{
+				"name": []string{"USR1"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`invalid parameter "name": "USR1"`),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:    "InvalidTarget",
+			inStart: true,
+			inQuery: url.Values{
+				"name":   []string{"INT"},
+				"target": []string{"all"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`invalid parameter "target": "all"`),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:    "NotRunning",
+			inStart: false,
+			inQuery: url.Values{
+				"name": []string{"INT"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusConflict,
+				Body: []byte(ErrNotRunning.Error()),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:    "NoForeground",
+			inStart: true,
+			inQuery: url.Values{
+				"name": []string{"INT"},
+			},
+			inErrSignal: xpty.ErrNoForeground,
+			wantResp: &ServiceResponse{
+				Code: http.StatusConflict,
+				Body: []byte(xpty.ErrNoForeground.Error()),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:    "ByInput",
+			inStart: true,
+			inQuery: url.Values{
+				"name": []string{"INT"},
+			},
+			inErrSignal: xpty.ErrSignalByInput,
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+			wantLog:   []byte{},
+			wantMTOut: []byte{0x03},
+		},
+		{
+			name:    "Unsupported",
+			inStart: true,
+			inQuery: url.Values{
+				"name": []string{"TSTP"},
+			},
+			inErrSignal: xpty.ErrUnsupported,
+			wantResp: &ServiceResponse{
+				Code: http.StatusNotImplemented,
+				Body: []byte(xpty.ErrUnsupported.Error()),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:    "ErrSignal",
+			inStart: true,
+			inQuery: url.Values{
+				"name": []string{"INT"},
+			},
+			inErrSignal: errDummy,
+			wantResp: &ServiceResponse{
+				Code: http.StatusInternalServerError,
+				Body: []byte("internal server error"),
+			},
+			wantLog: []byte("level=ERROR msg=\"internal error\" err=\"dummy error\"\n"),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{
+				ErrSignal: tc.inErrSignal,
+				PID:       pid,
+			}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+			}
+			slot := NewTermSlot(cfg)
+
+			if tc.inStart {
+				err := slot.start()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			logbuf := new(bytes.Buffer)
+			logger := newTestLogger(logbuf)
+
+			srv := &SignalService{
+				TermSlot: slot,
+				Logger:   logger,
+			}
+
+			gotResp := srv.ServeAPI(tc.inQuery)
+			gotLog := logbuf.Bytes()
+
+			if slot.term != nil {
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			gotMTOut := []byte{}
+			if tc.inStart {
+				gotMTOut, _ = io.ReadAll(mt.Computer())
+			}
+
+			if gotResp.Code != tc.wantResp.Code {
+				t.Errorf("resp code: expected %d, got %d", tc.wantResp.Code, gotResp.Code)
+			}
+			if !bytes.Equal(gotResp.Body, tc.wantResp.Body) {
+				t.Errorf("resp body: expected %#v, got %#v", string(tc.wantResp.Body), string(gotResp.Body))
+			}
+			if !bytes.Equal(gotLog, tc.wantLog) {
+				t.Errorf("log: expected %#v, got %#v", string(tc.wantLog), string(gotLog))
+			}
+			if mt.Signal != tc.wantSignal {
+				t.Errorf("mt signal: expected %#v, got %#v", tc.wantSignal, mt.Signal)
+			}
+			if mt.SignalTarget != tc.wantSignalTarget {
+				t.Errorf("mt signal target: expected %#v, got %#v", tc.wantSignalTarget, mt.SignalTarget)
+			}
+			if !bytes.Equal(gotMTOut, tc.wantMTOut) {
+				t.Errorf("mt out: expected %#v, got %#v", tc.wantMTOut, gotMTOut)
+			}
+		})
+	}
+}
+
+func TestServiceServeAPIInputDropped(t *testing.T) {
+	pid := os.Getpid()
+
+	tt := []struct {
+		name    string
+		inSrv   func(slot *TermSlot, logger *slog.Logger) Service
+		inQuery url.Values
+	}{
+		{
+			name: "Keyboard",
+			inSrv: func(slot *TermSlot, logger *slog.Logger) Service {
+				return &KeyboardService{TermSlot: slot, Logger: logger}
+			},
+			inQuery: url.Values{
+				"key": []string{"A"},
+			},
+		},
+		{
+			name: "Macro",
+			inSrv: func(slot *TermSlot, logger *slog.Logger) Service {
+				macros := map[string]Macro{
+					"list": {{Text: "ls"}, {Key: "Enter"}},
+				}
+				return &MacroService{TermSlot: slot, Macros: macros, Logger: logger}
+			},
+			inQuery: url.Values{
+				"name": []string{"list"},
+			},
+		},
+		{
+			name: "SignalByInput",
+			inSrv: func(slot *TermSlot, logger *slog.Logger) Service {
+				return &SignalService{TermSlot: slot, Logger: logger}
+			},
+			inQuery: url.Values{
+				"name": []string{"INT"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{
+				ErrSignal: xpty.ErrSignalByInput,
+				ErrWrite:  os.ErrClosed,
+				PID:       pid,
+			}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+			}
+			slot := NewTermSlot(cfg)
+
+			// The pty stops reading, so keyboard input piles up until vterm
+			// drops it.
+			var err error
+			for i := 0; i < 2*vterm.OutputBufferSize && err == nil; i++ {
+				err = slot.Keyboard("A", vterm.ModNone)
+			}
+			if !errors.Is(err, ErrInputDropped) {
+				t.Fatalf("fill: expected %#v, got %#v", ErrInputDropped, err)
+			}
+
+			logbuf := new(bytes.Buffer)
+			logger := newTestLogger(logbuf)
+
+			srv := tc.inSrv(slot, logger)
+			gotResp := srv.ServeAPI(tc.inQuery)
+			gotLog := logbuf.Bytes()
+
+			slot.term.pc = nil
+			slot.Stop()
+
+			wantResp := &ServiceResponse{
+				Code: http.StatusServiceUnavailable,
+				Body: []byte(ErrInputDropped.Error()),
+			}
+			if gotResp.Code != wantResp.Code {
+				t.Errorf("resp code: expected %d, got %d", wantResp.Code, gotResp.Code)
+			}
+			if !bytes.Equal(gotResp.Body, wantResp.Body) {
+				t.Errorf("resp body: expected %#v, got %#v", string(wantResp.Body), string(gotResp.Body))
+			}
+			if len(gotLog) > 0 {
+				t.Errorf("log: expected empty, got %#v", string(gotLog))
+			}
+		})
+	}
+}
+
+func TestStatusServiceServeAPI(t *testing.T) {
+	errDummy := errors.New("dummy error")
+	pid := os.Getpid()
+
+	tt := []struct {
+		name            string
+		inStart         bool
+		inExited        bool
+		inForeground    xpty.Process
+		inErrForeground error
+		wantResp        *ServiceResponse
+		wantLog         []byte
+	}{
+		{
+			name:    "NotRunning",
+			inStart: false,
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("running=false"),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:         "Idle",
+			inStart:      true,
+			inForeground: xpty.Process{PID: pid, Name: "bash"},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("col=120&command=bash&idle=true&pid=" + strconv.Itoa(pid) + "&row=30&running=true"),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:         "Busy",
+			inStart:      true,
+			inForeground: xpty.Process{PID: pid + 1, Name: "vim"},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("col=120&command=vim&idle=false&pid=" + strconv.Itoa(pid+1) + "&row=30&running=true"),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:            "NoForeground",
+			inStart:         true,
+			inErrForeground: xpty.ErrNoForeground,
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("col=120&row=30&running=true"),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:     "Exited",
+			inStart:  true,
+			inExited: true,
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("col=120&exit_code=2&row=30&running=false"),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:            "ErrForeground",
+			inStart:         true,
+			inErrForeground: errDummy,
+			wantResp: &ServiceResponse{
+				Code: http.StatusInternalServerError,
+				Body: []byte("internal server error"),
+			},
+			wantLog: []byte("level=ERROR msg=\"internal error\" err=\"dummy error\"\n"),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{
+				ErrForeground: tc.inErrForeground,
+				PID:           pid,
+				Foreground:    tc.inForeground,
+			}
+			if tc.inExited {
+				mt.PID = startExiting(t, 2)
+			}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+			}
+			slot := NewTermSlot(cfg)
+
+			if tc.inStart {
+				err := slot.start()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.inExited {
+				<-slot.term.Done()
+			}
+
+			logbuf := new(bytes.Buffer)
+			logger := newTestLogger(logbuf)
+
+			srv := &StatusService{
+				TermSlot: slot,
+				Logger:   logger,
+			}
+
+			gotResp := srv.ServeAPI(nil)
+			gotLog := logbuf.Bytes()
+			gotMTOpenTerminal := mt.OpenTerminal
+
+			if slot.term != nil {
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			if gotResp.Code != tc.wantResp.Code {
+				t.Errorf("resp code: expected %d, got %d", tc.wantResp.Code, gotResp.Code)
+			}
+			if !bytes.Equal(gotResp.Body, tc.wantResp.Body) {
+				t.Errorf("resp body: expected %#v, got %#v", string(tc.wantResp.Body), string(gotResp.Body))
+			}
+			if !bytes.Equal(gotLog, tc.wantLog) {
+				t.Errorf("log: expected %#v, got %#v", string(tc.wantLog), string(gotLog))
+			}
+			if gotMTOpenTerminal != tc.inStart {
+				t.Errorf("mt open: expected %t, got %t", tc.inStart, gotMTOpenTerminal)
+			}
+		})
+	}
+}
+
+func TestStatusJSONServiceServeAPI(t *testing.T) {
+	errDummy := errors.New("dummy error")
+	pid := os.Getpid()
+
+	tt := []struct {
+		name            string
+		inErrForeground error
+		wantResp        *ServiceResponse
+		wantLog         []byte
+	}{
+		{
+			name:            "NotRunning",
+			inErrForeground: nil,
+			wantResp: &ServiceResponse{
+				Code:        http.StatusOK,
+				ContentType: "application/json",
+				Body:        []byte(`{"running":false}`),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:            "ErrForeground",
+			inErrForeground: errDummy,
+			wantResp: &ServiceResponse{
+				Code: http.StatusInternalServerError,
+				Body: []byte("internal server error"),
+			},
+			wantLog: []byte("level=ERROR msg=\"internal error\" err=\"dummy error\"\n"),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{
+				ErrForeground: tc.inErrForeground,
+				PID:           pid,
+			}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+			}
+			slot := NewTermSlot(cfg)
+
+			if tc.inErrForeground != nil {
+				err := slot.start()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			logbuf := new(bytes.Buffer)
+			logger := newTestLogger(logbuf)
+
+			srv := &StatusJSONService{
+				TermSlot: slot,
+				Logger:   logger,
+			}
+
+			gotResp := srv.ServeAPI(nil)
+			gotLog := logbuf.Bytes()
+
+			if slot.term != nil {
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			if gotResp.Code != tc.wantResp.Code {
+				t.Errorf("resp code: expected %d, got %d", tc.wantResp.Code, gotResp.Code)
+			}
+			if gotResp.ContentType != tc.wantResp.ContentType {
+				t.Errorf("resp content type: expected %#v, got %#v", tc.wantResp.ContentType, gotResp.ContentType)
+			}
+			if !bytes.Equal(gotResp.Body, tc.wantResp.Body) {
+				t.Errorf("resp body: expected %#v, got %#v", string(tc.wantResp.Body), string(gotResp.Body))
+			}
+			if !bytes.Equal(gotLog, tc.wantLog) {
+				t.Errorf("log: expected %#v, got %#v", string(tc.wantLog), string(gotLog))
+			}
+		})
+	}
+}
+
+func TestThemeServiceServeAPI(t *testing.T) {
+	pid := os.Getpid()
+
+	tt := []struct {
+		name      string
+		inQuery   url.Values
+		wantResp  *ServiceResponse
+		wantTheme string
+	}{
+		{
+			name: "Normal",
+			inQuery: url.Values{
+				"name": []string{"solarized"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+			wantTheme: "solarized",
+		},
+		{
+			name:    "MissingName",
+			inQuery: url.Values{},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`missing parameter "name"`),
+			},
+			wantTheme: "default",
+		},
+		{
+			name: "InvalidName",
+			inQuery: url.Values{
+				"name": []string{"unknown"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`invalid parameter "name": "unknown"`),
+			},
+			wantTheme: "default",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{PID: pid}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+				Theme: "default",
+			}
+			slot := NewTermSlot(cfg)
+
+			logbuf := new(bytes.Buffer)
+			logger := newTestLogger(logbuf)
+
+			srv := &ThemeService{
+				TermSlot: slot,
+				Logger:   logger,
+			}
+
+			gotResp := srv.ServeAPI(tc.inQuery)
+			gotTheme := slot.cfg.Theme
+			gotLog := logbuf.Bytes()
+			gotMTOpenTerminal := mt.OpenTerminal
+			slot.Stop()
+
+			if gotResp.Code != tc.wantResp.Code {
+				t.Errorf("resp code: expected %d, got %d", tc.wantResp.Code, gotResp.Code)
+			}
+			if !bytes.Equal(gotResp.Body, tc.wantResp.Body) {
+				t.Errorf("resp body: expected %#v, got %#v", string(tc.wantResp.Body), string(gotResp.Body))
+			}
+			if gotTheme != tc.wantTheme {
+				t.Errorf("theme: expected %q, got %q", tc.wantTheme, gotTheme)
+			}
+			if len(gotLog) != 0 {
+				t.Errorf("log: expected empty, got %#v", string(gotLog))
+			}
+			if gotMTOpenTerminal {
+				t.Errorf("mt open: expected false, got true")
+			}
+		})
+	}
+}
+
+func TestClipboardServiceServeAPI(t *testing.T) {
+	pid := os.Getpid()
+
+	tt := []struct {
+		name     string
+		inStart  bool
+		inQuery  url.Values
+		wantResp *ServiceResponse
+		wantClip []byte
+	}{
+		{
+			name:    "GetNotRunning",
+			inStart: false,
+			inQuery: url.Values{},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+		},
+		{
+			name:    "SetNotRunning",
+			inStart: false,
+			inQuery: url.Values{
+				"data": []string{"hello"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusConflict,
+				Body: []byte(ErrNotRunning.Error()),
+			},
+		},
+		{
+			name:    "Get",
+			inStart: true,
+			inQuery: url.Values{},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+			wantClip: []byte{},
+		},
+		{
+			name:    "Set",
+			inStart: true,
+			inQuery: url.Values{
+				"data": []string{"hello"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+			wantClip: []byte("hello"),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{PID: pid}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+			}
+			slot := NewTermSlot(cfg)
+
+			if tc.inStart {
+				err := slot.start()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			logbuf := new(bytes.Buffer)
+			logger := newTestLogger(logbuf)
+
+			srv := &ClipboardService{
+				TermSlot: slot,
+				Logger:   logger,
+			}
+
+			gotResp := srv.ServeAPI(tc.inQuery)
+			gotLog := logbuf.Bytes()
+
+			var gotClip []byte
+			if slot.term != nil {
+				gotClip = slot.term.Clipboard()
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			if gotResp.Code != tc.wantResp.Code {
+				t.Errorf("resp code: expected %d, got %d", tc.wantResp.Code, gotResp.Code)
+			}
+			if !bytes.Equal(gotResp.Body, tc.wantResp.Body) {
+				t.Errorf("resp body: expected %#v, got %#v", string(tc.wantResp.Body), string(gotResp.Body))
+			}
+			if !bytes.Equal(gotClip, tc.wantClip) {
+				t.Errorf("clipboard: expected %#v, got %#v", string(tc.wantClip), string(gotClip))
+			}
+			if len(gotLog) != 0 {
+				t.Errorf("log: expected empty, got %#v", string(gotLog))
+			}
+		})
+	}
+}
+
+func TestCopyServiceServeAPI(t *testing.T) {
+	pid := os.Getpid()
+
+	tt := []struct {
+		name     string
+		inStart  bool
+		inQuery  url.Values
+		wantResp *ServiceResponse
+	}{
+		{
+			name:    "All",
+			inStart: true,
+			inQuery: url.Values{},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("AB\nC"),
+			},
+		},
+		{
+			name:    "Rect",
+			inStart: true,
+			inQuery: url.Values{
+				"start_row": []string{"0"},
+				"end_row":   []string{"1"},
+				"start_col": []string{"1"},
+				"end_col":   []string{"2"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("B"),
+			},
+		},
+		{
+			name:    "InvalidParam",
+			inStart: true,
+			inQuery: url.Values{
+				"end_col": []string{"-1"},
+			},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`failed to parse parameter "end_col": strconv.ParseUint: parsing "-1": invalid syntax`),
+			},
+		},
+		{
+			name:    "NotRunning",
+			inStart: false,
+			inQuery: url.Values{},
+			wantResp: &ServiceResponse{
+				Code: http.StatusConflict,
+				Body: []byte(ErrNotRunning.Error()),
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{PID: pid}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  2,
+				Col:  2,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+			}
+			slot := NewTermSlot(cfg)
+
+			if tc.inStart {
+				err := slot.start()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mc := mt.Computer()
+				_, err = mc.Write([]byte("AB\r\nC"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = mc.Write([]byte{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			logbuf := new(bytes.Buffer)
+			logger := newTestLogger(logbuf)
+
+			srv := &CopyService{
+				TermSlot: slot,
+				Logger:   logger,
+			}
+
+			gotResp := srv.ServeAPI(tc.inQuery)
+			gotLog := logbuf.Bytes()
+
+			var gotClip []byte
+			if slot.term != nil {
+				gotClip = slot.term.Clipboard()
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			if gotResp.Code != tc.wantResp.Code {
+				t.Errorf("resp code: expected %d, got %d", tc.wantResp.Code, gotResp.Code)
+			}
+			if !bytes.Equal(gotResp.Body, tc.wantResp.Body) {
+				t.Errorf("resp body: expected %#v, got %#v", string(tc.wantResp.Body), string(gotResp.Body))
+			}
+			if gotResp.Code == http.StatusOK && !bytes.Equal(gotClip, tc.wantResp.Body) {
+				t.Errorf("clipboard: expected %#v, got %#v", string(tc.wantResp.Body), string(gotClip))
+			}
+			if len(gotLog) != 0 {
+				t.Errorf("log: expected empty, got %#v", string(gotLog))
+			}
+		})
+	}
+}
+
+func TestSequenceServiceServeAPI(t *testing.T) {
+	pid := os.Getpid()
+
+	tt := []struct {
+		name     string
+		inStart  bool
+		inIn     []byte
+		wantResp *ServiceResponse
+	}{
+		{
+			name:    "NotRunning",
+			inStart: false,
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+		},
+		{
+			name:    "Empty",
+			inStart: true,
+			inIn:    []byte("\x1B]7778;lights\x07"),
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+		},
+		{
+			name:    "Normal",
+			inStart: true,
+			inIn:    []byte("\x1B]7777;horn\x07\x1B]7777;a&b\x07\x1BP+qabc\x1B\\"),
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("command=7777&data=horn&kind=osc\ncommand=7777&data=a%26b&kind=osc\ncommand=%2Bq&data=abc&kind=dcs\n"),
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{PID: pid}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+				OSCAllow: []int{7777},
+				DCSAllow: true,
+			}
+			slot := NewTermSlot(cfg)
+
+			if tc.inStart {
+				err := slot.start()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mc := mt.Computer()
+				_, err = mc.Write(tc.inIn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = mc.Write([]byte{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			logbuf := new(bytes.Buffer)
+			logger := newTestLogger(logbuf)
+
+			srv := &SequenceService{
+				TermSlot: slot,
+				Logger:   logger,
+			}
+
+			gotResp := srv.ServeAPI(nil)
+			gotLog := logbuf.Bytes()
+
+			if slot.term != nil {
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			if gotResp.Code != tc.wantResp.Code {
+				t.Errorf("resp code: expected %d, got %d", tc.wantResp.Code, gotResp.Code)
+			}
+			if !bytes.Equal(gotResp.Body, tc.wantResp.Body) {
+				t.Errorf("resp body: expected %#v, got %#v", string(tc.wantResp.Body), string(gotResp.Body))
+			}
+			if len(gotLog) != 0 {
+				t.Errorf("log: expected empty, got %#v", string(gotLog))
+			}
+		})
+	}
+}
+
+func TestEventServiceServeAPI(t *testing.T) {
+	pid := os.Getpid()
+
+	tt := []struct {
+		name     string
+		inStart  bool
+		inIn     []byte
+		inQuery  url.Values
+		wantResp *ServiceResponse
+	}{
+		{
+			name:    "NotRunning",
+			inStart: false,
+			inQuery: url.Values{},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("seq=0\n"),
+			},
+		},
+		{
+			name:    "Empty",
+			inStart: true,
+			inIn:    []byte("hello"),
+			inQuery: url.Values{},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("seq=0\n"),
+			},
+		},
+		{
+			name:    "Normal",
+			inStart: true,
+			inIn:    []byte("\x07\x1B]2;a&b\x07"),
+			inQuery: url.Values{},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("seq=2\ndata=&seq=1&type=bell\ndata=a%26b&seq=2&type=title\n"),
+			},
+		},
+		{
+			name:    "Since",
+			inStart: true,
+			inIn:    []byte("\x07\x1B]2;a&b\x07"),
+			inQuery: url.Values{"since": []string{"1"}},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte("seq=2\ndata=a%26b&seq=2&type=title\n"),
+			},
+		},
+		{
+			name:    "InvalidSince",
+			inStart: false,
+			inQuery: url.Values{"since": []string{"-1"}},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`failed to parse parameter "since": strconv.ParseUint: parsing "-1": invalid syntax`),
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{PID: pid}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+			}
+			slot := NewTermSlot(cfg)
+
+			if tc.inStart {
+				err := slot.start()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mc := mt.Computer()
+				_, err = mc.Write(tc.inIn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = mc.Write([]byte{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			logbuf := new(bytes.Buffer)
+			logger := newTestLogger(logbuf)
+
+			srv := &EventService{
+				TermSlot: slot,
+				Logger:   logger,
+			}
+
+			gotResp := srv.ServeAPI(tc.inQuery)
+			gotLog := logbuf.Bytes()
+
+			if slot.term != nil {
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			if gotResp.Code != tc.wantResp.Code {
+				t.Errorf("resp code: expected %d, got %d", tc.wantResp.Code, gotResp.Code)
+			}
+			if !bytes.Equal(gotResp.Body, tc.wantResp.Body) {
+				t.Errorf("resp body: expected %#v, got %#v", string(tc.wantResp.Body), string(gotResp.Body))
+			}
+			if len(gotLog) != 0 {
+				t.Errorf("log: expected empty, got %#v", string(gotLog))
+			}
+		})
+	}
+}
+
+func newPostRequest(target, contentType, body string) *http.Request {
+	req := httptest.NewRequest("POST", target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req
+}
+
+type MockService struct {
+	Query url.Values
+	Resp  *ServiceResponse
 }
 
 func (srv *MockService) ServeAPI(query url.Values) *ServiceResponse {
 	srv.Query = query
 	return srv.Resp
 }
+
+// newTestLogger returns a text logger that omits the time so that the
+// output is reproducible.
+func newTestLogger(w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+func TestHealthServiceServeAPI(t *testing.T) {
+	srv := &HealthService{}
+	gotResp := srv.ServeAPI(url.Values{})
+	if gotResp.Code != http.StatusOK {
+		t.Errorf("resp code: expected %d, got %d", http.StatusOK, gotResp.Code)
+	}
+	if string(gotResp.Body) != "ok" {
+		t.Errorf("resp body: expected %#v, got %#v", "ok", string(gotResp.Body))
+	}
+}
+
+func TestReadyServiceServeAPI(t *testing.T) {
+	errDummy := errors.New("dummy error")
+	pid := os.Getpid()
+
+	tt := []struct {
+		name      string
+		inStart   bool
+		inErrOpen error
+		inTheme   string
+		wantCode  int
+		wantBody  []byte
+		wantLog   []byte
+	}{
+		{
+			name:     "NotRunning",
+			wantCode: http.StatusOK,
+			wantBody: []byte("ok"),
+			wantLog:  []byte{},
+		},
+		{
+			name:     "Running",
+			inStart:  true,
+			wantCode: http.StatusOK,
+			wantBody: []byte("ok"),
+			wantLog:  []byte{},
+		},
+		{
+			name:      "OpenError",
+			inErrOpen: errDummy,
+			wantCode:  http.StatusServiceUnavailable,
+			wantBody:  []byte("not ready"),
+			wantLog:   []byte("level=WARN msg=\"not ready\" err=\"dummy error\"\n"),
+		},
+		{
+			name:     "InvalidTheme",
+			inTheme:  "nonexistent",
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: []byte("not ready"),
+			wantLog:  []byte("level=WARN msg=\"not ready\" err=\"invalid theme\"\n"),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{PID: pid}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+				Theme: tc.inTheme,
+			}
+			slot := NewTermSlot(cfg)
+
+			if tc.inStart {
+				err := slot.start()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			mt.ErrOpen = tc.inErrOpen
+
+			logbuf := new(bytes.Buffer)
+			srv := &ReadyService{
+				TermSlot: slot,
+				Logger:   newTestLogger(logbuf),
+			}
+
+			gotResp := srv.ServeAPI(url.Values{})
+			gotLog := logbuf.Bytes()
+			gotStarted := slot.term != nil
+
+			if slot.term != nil {
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			if gotResp.Code != tc.wantCode {
+				t.Errorf("resp code: expected %d, got %d", tc.wantCode, gotResp.Code)
+			}
+			if !bytes.Equal(gotResp.Body, tc.wantBody) {
+				t.Errorf("resp body: expected %#v, got %#v", string(tc.wantBody), string(gotResp.Body))
+			}
+			if !bytes.Equal(gotLog, tc.wantLog) {
+				t.Errorf("log: expected %#v, got %#v", string(tc.wantLog), string(gotLog))
+			}
+			if gotStarted != tc.inStart {
+				t.Errorf("started: expected %t, got %t", tc.inStart, gotStarted)
+			}
+			if !tc.inStart && mt.OpenTerminal {
+				t.Errorf("terminal left open")
+			}
+		})
+	}
+}
+
+func TestStartServiceServeAPI(t *testing.T) {
+	errDummy := errors.New("dummy error")
+	pid := os.Getpid()
+
+	tt := []struct {
+		name      string
+		inRunning bool
+		inErrOpen error
+		inQuery   url.Values
+		wantResp  *ServiceResponse
+		wantLog   []byte
+		wantCmd   xpty.Cmd
+	}{
+		{
+			name:    "Default",
+			inQuery: url.Values{},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+			wantLog: []byte{},
+			wantCmd: xpty.Cmd{Path: "bash", Args: []string{"bash"}},
+		},
+		{
+			name:    "Profile",
+			inQuery: url.Values{"profile": {"htop"}, "row": {"10"}, "col": {"20"}},
+			wantResp: &ServiceResponse{
+				Code: http.StatusOK,
+				Body: []byte{},
+			},
+			wantLog: []byte{},
+			wantCmd: xpty.Cmd{Path: "htop", Args: []string{"htop"}},
+		},
+		{
+			name:    "UnknownProfile",
+			inQuery: url.Values{"profile": {"rm -rf /"}},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`invalid parameter "profile": "rm -rf /"`),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:    "InvalidRow",
+			inQuery: url.Values{"row": {"x"}},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`failed to parse parameter "row": strconv.ParseUint: parsing "x": invalid syntax`),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:    "TooLarge",
+			inQuery: url.Values{"col": {"1001"}},
+			wantResp: &ServiceResponse{
+				Code: http.StatusBadRequest,
+				Body: []byte(`invalid parameter "col": "1001"`),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:      "Running",
+			inRunning: true,
+			inQuery:   url.Values{"profile": {"htop"}},
+			wantResp: &ServiceResponse{
+				Code: http.StatusConflict,
+				Body: []byte("terminal already running"),
+			},
+			wantLog: []byte{},
+		},
+		{
+			name:      "Error",
+			inErrOpen: errDummy,
+			inQuery:   url.Values{},
+			wantResp: &ServiceResponse{
+				Code: http.StatusInternalServerError,
+				Body: []byte("internal server error"),
+			},
+			wantLog: []byte("level=ERROR msg=\"internal error\" err=\"dummy error\"\n"),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{PID: pid}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+				Profiles: map[string]Profile{
+					"shell": {Cmd: xpty.Cmd{Path: "bash", Args: []string{"bash"}}},
+					"htop":  {Cmd: xpty.Cmd{Path: "htop", Args: []string{"htop"}}},
+				},
+			}
+			slot := NewTermSlot(cfg)
+
+			if tc.inRunning {
+				err := slot.start()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			mt.ErrOpen = tc.inErrOpen
+
+			logbuf := new(bytes.Buffer)
+			srv := &StartService{
+				TermSlot: slot,
+				Logger:   newTestLogger(logbuf),
+			}
+
+			gotResp := srv.ServeAPI(tc.inQuery)
+			gotLog := logbuf.Bytes()
+			gotCmd := mt.Cmd
+
+			if slot.term != nil {
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			if gotResp.Code != tc.wantResp.Code {
+				t.Errorf("resp code: expected %d, got %d", tc.wantResp.Code, gotResp.Code)
+			}
+			if !bytes.Equal(gotResp.Body, tc.wantResp.Body) {
+				t.Errorf("resp body: expected %#v, got %#v", string(tc.wantResp.Body), string(gotResp.Body))
+			}
+			if !bytes.Equal(gotLog, tc.wantLog) {
+				t.Errorf("log: expected %#v, got %#v", string(tc.wantLog), string(gotLog))
+			}
+			if tc.wantResp.Code == http.StatusOK && !reflect.DeepEqual(gotCmd, tc.wantCmd) {
+				t.Errorf("cmd: expected %#v, got %#v", tc.wantCmd, gotCmd)
+			}
+		})
+	}
+}
@@ -3,8 +3,12 @@ package main
 import (
 	"errors"
 	"io"
+	"log/slog"
 	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gcrtnst/sw-term-server/internal/vterm"
 	"github.com/gcrtnst/sw-term-server/internal/xpty"
@@ -15,13 +19,26 @@ type Term struct {
 	ps xpty.Session
 	vt *vterm.VTerm
 	pc *os.Process
+	st time.Time
+	th *Throttle
+	po *countWriter
+	lg *slog.Logger
+	dl sync.Once
 
 	oc sync.Once
 	di <-chan struct{}
 	do <-chan struct{}
+	wo <-chan struct{}
+	we error
+	ws *os.ProcessState
 }
 
 func NewTerm(cfg TermConfig) (*Term, error) {
+	theme, ok := LookupTheme(cfg.Theme)
+	if !ok {
+		return nil, ErrInvalidTheme
+	}
+
 	pt, err := cfg.Open()
 	if err != nil {
 		return nil, err
@@ -31,30 +48,20 @@ func NewTerm(cfg TermConfig) (*Term, error) {
 	vt.Screen().SetAltScreen(true)
 	vt.Screen().SetReflow(false)
 
-	// FIXME: Enabling UTF-8 support in the current libvterm (v0.3.2) may cause
-	// crashes when displaying wide characters at the screen edge.
-	//
-	// The bug has been fixed in the libvterm bundled with Vim,
-	// but not in the original version.
-	// https://github.com/vim/vim/commit/8b89614e69b9b2330539d0482e44f4724053e780
 	vt.SetUTF8(true)
 
-	fg := vterm.NewColorRGB(0xC4, 0xC4, 0xC4)
-	bg := vterm.NewColorRGB(0x00, 0x00, 0x00)
-	vt.Screen().SetDefaultColor(fg, bg)
-	vt.Screen().SetPaletteColor(0, vterm.NewColorRGB(0x00, 0x00, 0x00))
-	vt.Screen().SetPaletteColor(1, vterm.NewColorRGB(0xC4, 0x40, 0x40))
-	vt.Screen().SetPaletteColor(2, vterm.NewColorRGB(0x40, 0xC4, 0x40))
-	vt.Screen().SetPaletteColor(3, vterm.NewColorRGB(0xC4, 0xC4, 0x40))
-	vt.Screen().SetPaletteColor(4, vterm.NewColorRGB(0x40, 0x40, 0xC4))
-	vt.Screen().SetPaletteColor(5, vterm.NewColorRGB(0xC4, 0x40, 0xC4))
-	vt.Screen().SetPaletteColor(6, vterm.NewColorRGB(0x40, 0xC4, 0xC4))
-	vt.Screen().SetPaletteColor(7, vterm.NewColorRGB(0xC4, 0xC4, 0xC4))
+	theme.Apply(vt.Screen())
+	vt.State().SetSequenceFilter(cfg.OSCAllow, cfg.DCSAllow)
 
+	eq := cfg.Events
+	if eq == nil {
+		eq = NewEventQueue(DefaultEventQueueSize)
+	}
+	th := NewThrottle(cfg.Throttle)
 	di := make(chan struct{})
-	vi := vt.Input()
+	vi := &eventWriter{vt: vt, eq: eq}
 	go func() {
-		_, err := io.Copy(vi, pt)
+		err := th.Copy(vi, pt)
 		if err != nil && !errors.Is(err, os.ErrClosed) {
 			panic(err)
 		}
@@ -63,8 +70,9 @@ func NewTerm(cfg TermConfig) (*Term, error) {
 
 	do := make(chan struct{})
 	vo := vt.Output()
+	po := &countWriter{w: pt}
 	go func() {
-		_, err := io.Copy(pt, vo)
+		_, err := io.Copy(po, vo)
 		if err != nil && !errors.Is(err, os.ErrClosed) {
 			panic(err)
 		}
@@ -100,33 +108,221 @@ func NewTerm(cfg TermConfig) (*Term, error) {
 		return nil, err
 	}
 
+	logger := cfg.logger()
+	wo := make(chan struct{})
 	t := &Term{
 		pt: pt,
 		ps: ps,
 		vt: vt,
 		pc: pc,
+		st: time.Now(),
+		th: th,
+		po: po,
+		lg: logger,
 		di: di,
 		do: do,
+		wo: wo,
 	}
+	go func() {
+		state, err := pc.Wait()
+		if err != nil {
+			t.we = err
+		} else {
+			t.ws = state
+			logger.Info("process exited", "pid", pc.Pid, "code", state.ExitCode())
+			eq.Push(EventExit, strconv.Itoa(state.ExitCode()))
+		}
+		close(wo)
+	}()
 	return t, nil
 }
 
-func (t *Term) Keyboard(key Key, mod vterm.Modifier) bool {
-	if vk, ok := key.VTermKey(); ok {
-		t.vt.KeyboardKey(vk, mod)
-		return true
+// Done returns a channel that is closed when the process has exited.
+func (t *Term) Done() <-chan struct{} {
+	return t.wo
+}
+
+// Exited reports whether the process has exited. A process that could not
+// be waited for is reported as running.
+func (t *Term) Exited() bool {
+	select {
+	case <-t.wo:
+		return t.ws != nil
+	default:
+		return false
+	}
+}
+
+// ExitCode returns the exit code of the process. It must be called after
+// Done is closed, and reports false if the exit status is unknown.
+func (t *Term) ExitCode() (int, bool) {
+	if t.ws == nil {
+		return 0, false
+	}
+	return t.ws.ExitCode(), true
+}
+
+func (t *Term) Keyboard(key Key, mod vterm.Modifier) error {
+	ks, ok := key.Parse()
+	if !ok {
+		return ErrInvalidKey
 	}
-	if r, ok := key.Rune(); ok {
-		t.vt.KeyboardRune(r, mod)
-		return true
+
+	mod |= ks.Mod
+	var err error
+	if ks.Key != vterm.KeyNone {
+		err = t.vt.KeyboardKey(ks.Key, mod)
+	} else {
+		err = t.vt.KeyboardRune(ks.Rune, mod)
+	}
+	return t.inputErr(err)
+}
+
+// Macro runs the steps of m in order. The keys must have been validated.
+// It stops at the first step whose input is dropped.
+func (t *Term) Macro(m Macro) error {
+	for _, step := range m {
+		var err error
+		switch {
+		case step.Key != "":
+			err = t.Keyboard(step.Key, step.Mod)
+		case step.Text != "":
+			for _, r := range step.Text {
+				err = t.inputErr(t.vt.KeyboardRune(r, vterm.ModNone))
+				if err != nil {
+					break
+				}
+			}
+		default:
+			time.Sleep(step.Delay)
+		}
+		if err != nil {
+			return err
+		}
 	}
-	return false
+	return nil
 }
 
 func (t *Term) CaptureRGB() vterm.ScreenShot {
 	return t.vt.Screen().CaptureRGB()
 }
 
+// CaptureIndexed captures the screen keeping the ANSI colors as indices.
+// Indexed colors outside the 16-color palette are resolved to RGB.
+func (t *Term) CaptureIndexed() (vterm.ScreenShot, Theme) {
+	scr := t.vt.Screen()
+	ss := scr.Capture()
+	for idx := range ss.Cell {
+		if ss.Cell[idx].FG.IsIndexed() && ss.Cell[idx].FG.Idx >= 16 {
+			ss.Cell[idx].FG = scr.ConvertColorToRGB(ss.Cell[idx].FG)
+		}
+		if ss.Cell[idx].BG.IsIndexed() && ss.Cell[idx].BG.Idx >= 16 {
+			ss.Cell[idx].BG = scr.ConvertColorToRGB(ss.Cell[idx].BG)
+		}
+	}
+
+	var pal Theme
+	pal.FG, pal.BG = scr.DefaultColor()
+	for idx := range pal.Palette {
+		pal.Palette[idx] = scr.PaletteColor(byte(idx))
+	}
+	return ss, pal
+}
+
+func (t *Term) Clipboard() []byte {
+	return t.vt.Screen().Clipboard()
+}
+
+func (t *Term) SetClipboard(b []byte) {
+	t.vt.Screen().SetClipboard(b)
+}
+
+// Copy extracts the text inside rect and stores it in the clipboard.
+func (t *Term) Copy(rect vterm.Rect) string {
+	scr := t.vt.Screen()
+	text := scr.Capture().Text(rect)
+	scr.SetClipboard([]byte(text))
+	return text
+}
+
+func (t *Term) TakeSequences() []vterm.Sequence {
+	return t.vt.State().TakeSequences()
+}
+
+func (t *Term) InputStats() ThrottleStats {
+	return t.th.Stats()
+}
+
+// OutputBytes returns the number of bytes written to the pty.
+func (t *Term) OutputBytes() uint64 {
+	return t.po.n.Load()
+}
+
+// OutputDropped returns the number of bytes of keyboard input discarded
+// because the pty did not read them fast enough.
+func (t *Term) OutputDropped() uint64 {
+	return t.vt.Output().Dropped()
+}
+
+// inputErr reports keyboard input discarded by vterm as ErrInputDropped.
+// The first drop is logged; later drops are only counted in the metrics.
+func (t *Term) inputErr(err error) error {
+	if !errors.Is(err, vterm.ErrOutputFull) {
+		return err
+	}
+	t.dl.Do(func() {
+		t.lg.Warn("keyboard input dropped; the pty is not reading its input", "bytes", t.OutputDropped())
+	})
+	return ErrInputDropped
+}
+
+func (t *Term) SetTheme(theme Theme) {
+	theme.Apply(t.vt.Screen())
+}
+
+func (t *Term) Signal(sig xpty.Signal, target xpty.SignalTarget) error {
+	err := t.ps.Signal(sig, target)
+	if errors.Is(err, xpty.ErrSignalByInput) && sig == xpty.SignalINT {
+		return t.inputErr(t.vt.KeyboardRune('c', vterm.ModCtrl))
+	}
+	return err
+}
+
+func (t *Term) Status() (TermStatus, error) {
+	rows, cols := t.vt.GetSize()
+	scr := t.vt.Screen()
+	st := TermStatus{
+		Running:       true,
+		Row:           rows,
+		Col:           cols,
+		CursorPos:     scr.CursorPos(),
+		CursorVisible: scr.CursorVisible(),
+		CursorBlink:   scr.CursorBlink(),
+		CursorShape:   scr.CursorShape(),
+		Title:         scr.Title(),
+		Started:       t.st,
+	}
+
+	if t.Exited() {
+		st.Running = false
+		st.Exited = true
+		st.ExitCode, _ = t.ExitCode()
+		return st, nil
+	}
+
+	fg, err := t.ps.ForegroundProcess()
+	if errors.Is(err, xpty.ErrUnsupported) || errors.Is(err, xpty.ErrNoForeground) {
+		return st, nil
+	}
+	if err != nil {
+		return TermStatus{}, err
+	}
+
+	st.Foreground = fg
+	st.Idle = t.pc != nil && fg.PID == t.pc.Pid
+	return st, nil
+}
+
 func (t *Term) Close() error {
 	t.oc.Do(t.close)
 	return nil
@@ -150,19 +346,102 @@ func (t *Term) close() {
 		panic(err)
 	}
 
-	if t.pc != nil {
-		_, err = t.pc.Wait()
-		if err != nil {
-			panic(err)
-		}
+	<-t.wo
+	if t.pc != nil && t.we != nil {
+		panic(t.we)
 	}
 
+	t.th.Stop()
 	<-t.di
 	<-t.do
 }
 
+// eventWriter feeds pty output to vterm and moves the screen events raised
+// by it into the event queue.
+type eventWriter struct {
+	vt *vterm.VTerm
+	eq *EventQueue
+}
+
+func (w *eventWriter) Write(p []byte) (int, error) {
+	n, err := w.vt.Input().Write(p)
+
+	scr := w.vt.Screen()
+	for _, ev := range scr.TakeEvents() {
+		switch ev {
+		case vterm.ScreenEventBell:
+			w.eq.Push(EventBell, "")
+		case vterm.ScreenEventTitle:
+			w.eq.Push(EventTitle, scr.Title())
+		case vterm.ScreenEventClipboard:
+			w.eq.Push(EventClipboard, "")
+		case vterm.ScreenEventResize:
+			rows, cols := w.vt.GetSize()
+			w.eq.Push(EventResize, strconv.Itoa(rows)+","+strconv.Itoa(cols))
+		}
+	}
+	return n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n atomic.Uint64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n.Add(uint64(n))
+	return n, err
+}
+
+type TermStatus struct {
+	Running    bool
+	Exited     bool
+	ExitCode   int
+	Row, Col   int
+	Foreground xpty.Process
+	Idle       bool
+
+	CursorPos     vterm.Pos
+	CursorVisible bool
+	CursorBlink   bool
+	CursorShape   vterm.CursorShape
+	Title         string
+	Started       time.Time
+}
+
 type TermConfig struct {
 	Open     func() (xpty.Terminal, error)
 	Row, Col int
 	Cmd      xpty.Cmd
+	Theme    string
+	OSCAllow []int
+	DCSAllow bool
+	Throttle ThrottleConfig
+	Restart  RestartPolicy
+	Logger   *slog.Logger
+
+	// RestartDelay is the wait before restarting an exited terminal, so
+	// that a command failing on startup does not restart in a tight loop.
+	// DefaultRestartDelay is used if it is zero.
+	RestartDelay time.Duration
+
+	// Restarted, if not nil, is called once the restart policy has handled
+	// an exited process, reporting whether the terminal was restarted.
+	Restarted func(ok bool)
+
+	// Events receives the events of the terminal. A queue of its own is
+	// created if Events is nil.
+	Events *EventQueue
+
+	// Profiles lists the commands that can be started by name with
+	// TermSlot.Start. Cmd is used when a terminal starts implicitly.
+	Profiles map[string]Profile
+}
+
+func (cfg TermConfig) logger() *slog.Logger {
+	if cfg.Logger == nil {
+		return discardLogger
+	}
+	return cfg.Logger
 }
@@ -5,8 +5,12 @@ import (
 	"errors"
 	"io"
 	"os"
+	"os/exec"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gcrtnst/sw-term-server/internal/vterm"
 	"github.com/gcrtnst/sw-term-server/internal/xpty"
@@ -192,42 +196,42 @@ func TestTermKeyboard(t *testing.T) {
 		name    string
 		inKey   Key
 		inMod   vterm.Modifier
-		wantOK  bool
+		wantErr error
 		wantOut []byte
 	}{
 		{
 			name:    "NormalA",
 			inKey:   "A",
 			inMod:   vterm.ModNone,
-			wantOK:  true,
+			wantErr: nil,
 			wantOut: []byte("A"),
 		},
 		{
 			name:    "ModA",
 			inKey:   "A",
 			inMod:   vterm.ModShift | vterm.ModAlt | vterm.ModCtrl,
-			wantOK:  true,
+			wantErr: nil,
 			wantOut: []byte("\x1B[65;7u"),
 		},
 		{
 			name:    "NormalEnter",
 			inKey:   "Enter",
 			inMod:   vterm.ModNone,
-			wantOK:  true,
+			wantErr: nil,
 			wantOut: []byte("\r"),
 		},
 		{
 			name:    "ModEnter",
 			inKey:   "Enter",
 			inMod:   vterm.ModShift | vterm.ModAlt | vterm.ModCtrl,
-			wantOK:  true,
+			wantErr: nil,
 			wantOut: []byte("\x1B[13;8u"),
 		},
 		{
 			name:    "Invalid",
 			inKey:   "",
 			inMod:   vterm.ModNone,
-			wantOK:  false,
+			wantErr: ErrInvalidKey,
 			wantOut: []byte(""),
 		},
 	}
@@ -252,7 +256,7 @@ func TestTermKeyboard(t *testing.T) {
 			}
 			term.pc = nil
 
-			gotOK := term.Keyboard(tc.inKey, tc.inMod)
+			gotErr := term.Keyboard(tc.inKey, tc.inMod)
 			err = term.Close()
 			if err != nil {
 				panic(err)
@@ -261,8 +265,8 @@ func TestTermKeyboard(t *testing.T) {
 			mc := mt.Computer()
 			gotOut, _ := io.ReadAll(mc)
 
-			if gotOK != tc.wantOK {
-				t.Errorf("ok: expected %t, got %t", tc.wantOK, gotOK)
+			if gotErr != tc.wantErr {
+				t.Errorf("err: expected %#v, got %#v", tc.wantErr, gotErr)
 			}
 			if !bytes.Equal(gotOut, tc.wantOut) {
 				t.Errorf("out: expected %#v, got %#v", tc.wantOut, gotOut)
@@ -301,3 +305,239 @@ func TestTermClose(t *testing.T) {
 		t.Errorf("close 2: %s", err.Error())
 	}
 }
+
+func TestTermStatus(t *testing.T) {
+	errDummy := errors.New("dummy error")
+	pid := os.Getpid()
+
+	tt := []struct {
+		name            string
+		inForeground    xpty.Process
+		inErrForeground error
+		wantStatus      TermStatus
+		wantErr         error
+	}{
+		{
+			name:            "Idle",
+			inForeground:    xpty.Process{PID: pid, Name: "bash"},
+			inErrForeground: nil,
+			wantStatus: TermStatus{
+				Running:       true,
+				Row:           30,
+				Col:           120,
+				Foreground:    xpty.Process{PID: pid, Name: "bash"},
+				Idle:          true,
+				CursorVisible: true,
+				CursorBlink:   true,
+				CursorShape:   vterm.CursorShapeBlock,
+			},
+			wantErr: nil,
+		},
+		{
+			name:            "Busy",
+			inForeground:    xpty.Process{PID: pid + 1, Name: "vim"},
+			inErrForeground: nil,
+			wantStatus: TermStatus{
+				Running:       true,
+				Row:           30,
+				Col:           120,
+				Foreground:    xpty.Process{PID: pid + 1, Name: "vim"},
+				Idle:          false,
+				CursorVisible: true,
+				CursorBlink:   true,
+				CursorShape:   vterm.CursorShapeBlock,
+			},
+			wantErr: nil,
+		},
+		{
+			name:            "NoForeground",
+			inForeground:    xpty.Process{},
+			inErrForeground: xpty.ErrNoForeground,
+			wantStatus: TermStatus{
+				Running:       true,
+				Row:           30,
+				Col:           120,
+				CursorVisible: true,
+				CursorBlink:   true,
+				CursorShape:   vterm.CursorShapeBlock,
+			},
+			wantErr: nil,
+		},
+		{
+			name:            "Unsupported",
+			inForeground:    xpty.Process{},
+			inErrForeground: xpty.ErrUnsupported,
+			wantStatus: TermStatus{
+				Running:       true,
+				Row:           30,
+				Col:           120,
+				CursorVisible: true,
+				CursorBlink:   true,
+				CursorShape:   vterm.CursorShapeBlock,
+			},
+			wantErr: nil,
+		},
+		{
+			name:            "ErrForeground",
+			inForeground:    xpty.Process{},
+			inErrForeground: errDummy,
+			wantStatus:      TermStatus{},
+			wantErr:         errDummy,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{
+				ErrForeground: tc.inErrForeground,
+				PID:           pid,
+				Foreground:    tc.inForeground,
+			}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+			}
+
+			term, err := NewTerm(cfg)
+			if err != nil {
+				t.Fatalf("new: %s", err.Error())
+			}
+
+			gotStatus, gotErr := term.Status()
+			term.pc = nil
+			err = term.Close()
+			if err != nil {
+				panic(err)
+			}
+
+			if gotStatus.Running && gotStatus.Started.IsZero() {
+				t.Errorf("status started: zero")
+			}
+			gotStatus.Started = time.Time{}
+
+			if gotStatus != tc.wantStatus {
+				t.Errorf("status: expected %#v, got %#v", tc.wantStatus, gotStatus)
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("err: expected %#v, got %#v", tc.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+// startExiting starts a child process exiting with code and returns its
+// PID, to be handed to a MockTerminal so that the terminal can wait for it.
+func startExiting(t *testing.T, code int) int {
+	path, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+
+	args := []string{"sh", "-c", "exit " + strconv.Itoa(code)}
+	proc, err := os.StartProcess(path, args, &os.ProcAttr{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := proc.Pid
+	_ = proc.Release()
+	return pid
+}
+
+func TestTermStatusExited(t *testing.T) {
+	mt := &xpty.MockTerminal{PID: startExiting(t, 3)}
+	cfg := TermConfig{
+		Open: mt.Open,
+		Row:  30,
+		Col:  120,
+		Cmd: xpty.Cmd{
+			Path: "sh",
+			Args: []string{"sh"},
+		},
+	}
+
+	term, err := NewTerm(cfg)
+	if err != nil {
+		t.Fatalf("new: %s", err.Error())
+	}
+	<-term.Done()
+
+	st, err := term.Status()
+	if err != nil {
+		t.Fatalf("status: %s", err.Error())
+	}
+	err = term.Close()
+	if err != nil {
+		t.Fatalf("close: %s", err.Error())
+	}
+	if st.Running {
+		t.Errorf("running: expected false, got true")
+	}
+	if !st.Exited {
+		t.Errorf("exited: expected true, got false")
+	}
+	if st.ExitCode != 3 {
+		t.Errorf("exit code: expected 3, got %d", st.ExitCode)
+	}
+}
+
+// stalledTerminal is a terminal whose writes block until unblock is closed,
+// like a pty whose process does not read its input.
+type stalledTerminal struct {
+	xpty.Terminal
+	unblock chan struct{}
+}
+
+func (t *stalledTerminal) Write(p []byte) (int, error) {
+	<-t.unblock
+	return t.Terminal.Write(p)
+}
+
+func TestTermOutputDropped(t *testing.T) {
+	mt := &xpty.MockTerminal{PID: os.Getpid()}
+	st := &stalledTerminal{unblock: make(chan struct{})}
+	logbuf := new(bytes.Buffer)
+	cfg := TermConfig{
+		Open: func() (xpty.Terminal, error) {
+			pt, err := mt.Open()
+			st.Terminal = pt
+			return st, err
+		},
+		Row: 30,
+		Col: 120,
+		Cmd: xpty.Cmd{
+			Path: "bash",
+			Args: []string{"--version"},
+		},
+		Logger: newTestLogger(logbuf),
+	}
+
+	term, err := NewTerm(cfg)
+	if err != nil {
+		t.Fatalf("new: %s", err.Error())
+	}
+	for i := 0; i < 2*vterm.OutputBufferSize; i++ {
+		_ = term.Keyboard("a", vterm.ModNone)
+	}
+	gotDropped := term.OutputDropped()
+
+	close(st.unblock)
+	term.pc = nil
+	err = term.Close()
+	if err != nil {
+		t.Fatalf("close: %s", err.Error())
+	}
+
+	if gotDropped <= 0 {
+		t.Errorf("dropped: expected positive, got %d", gotDropped)
+	}
+	gotLog := logbuf.String()
+	if n := strings.Count(gotLog, "keyboard input dropped"); n != 1 {
+		t.Errorf("log: expected 1 drop message, got %d: %s", n, gotLog)
+	}
+}
@@ -3,23 +3,96 @@ package main
 import (
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/gcrtnst/sw-term-server/internal/vterm"
+	"github.com/gcrtnst/sw-term-server/internal/xpty"
 )
 
-var ErrInvalidKey = errors.New("invalid key")
+var (
+	ErrAlreadyRunning = errors.New("terminal already running")
+	ErrInputDropped   = errors.New("keyboard input dropped")
+	ErrInvalidKey     = errors.New("invalid key")
+	ErrInvalidTheme   = errors.New("invalid theme")
+	ErrNotRunning     = errors.New("terminal not running")
+	ErrUnknownProfile = errors.New("unknown profile")
+)
+
+const DefaultRestartDelay = time.Second
 
 type TermSlot struct {
 	mu   sync.Mutex
 	cfg  TermConfig
 	term *Term
+
+	// in serializes keyboard input, so that a macro is not interleaved with
+	// other input. It is locked before mu.
+	in sync.Mutex
+
+	// The event queue outlives the terminals, so that sequence numbers
+	// keep increasing across restarts.
+	eq *EventQueue
+
+	// Counters of terminals that have already been stopped.
+	starts       uint64
+	restarts     uint64
+	ptyIn        uint64
+	ptyProcessed uint64
+	ptyChunks    uint64
+	ptyOut       uint64
+	ptyDropped   uint64
+}
+
+type SlotStats struct {
+	Starts       uint64
+	Restarts     uint64
+	PtyIn        uint64
+	PtyProcessed uint64
+	PtyChunks    uint64
+	PtyOut       uint64
+	PtyDropped   uint64
 }
 
 func NewTermSlot(cfg TermConfig) *TermSlot {
-	return &TermSlot{cfg: cfg}
+	return &TermSlot{
+		cfg: cfg,
+		eq:  NewEventQueue(DefaultEventQueueSize),
+	}
+}
+
+// Start starts the named profile with the given size. A zero row or col
+// keeps the configured size. A terminal whose process has exited is
+// replaced.
+func (s *TermSlot) Start(profile string, row, col int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.term != nil && !s.term.Exited() {
+		return ErrAlreadyRunning
+	}
+
+	p, ok := s.cfg.Profiles[profile]
+	if !ok {
+		return ErrUnknownProfile
+	}
+
+	if s.term != nil {
+		s.stop()
+	}
+
+	cfg := p.Apply(s.cfg)
+	if row > 0 {
+		cfg.Row = row
+	}
+	if col > 0 {
+		cfg.Col = col
+	}
+	return s.startWith(cfg)
 }
 
 func (s *TermSlot) Keyboard(key Key, mod vterm.Modifier) error {
+	s.in.Lock()
+	defer s.in.Unlock()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -28,19 +101,39 @@ func (s *TermSlot) Keyboard(key Key, mod vterm.Modifier) error {
 		return err
 	}
 
-	ok := s.term.Keyboard(key, mod)
-	if !ok {
-		return ErrInvalidKey
+	return s.term.Keyboard(key, mod)
+}
+
+// Macro runs m holding only the input lock, so that no other input is
+// interleaved while other requests are served during its delays. If the
+// terminal is stopped halfway through, the rest of the input is discarded.
+func (s *TermSlot) Macro(m Macro) error {
+	err := m.Validate()
+	if err != nil {
+		return err
 	}
 
-	return nil
+	s.in.Lock()
+	defer s.in.Unlock()
+
+	s.mu.Lock()
+	err = s.start()
+	term := s.term
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return term.Macro(m)
 }
 
-func (s *TermSlot) CaptureRGB() (vterm.ScreenShot, error) {
+// CaptureRGB captures the screen. If the terminal is not running, it is
+// started if start is true, and ErrNotRunning is returned otherwise.
+func (s *TermSlot) CaptureRGB(start bool) (vterm.ScreenShot, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	err := s.start()
+	err := s.startIf(start)
 	if err != nil {
 		return vterm.ScreenShot{}, err
 	}
@@ -49,20 +142,166 @@ func (s *TermSlot) CaptureRGB() (vterm.ScreenShot, error) {
 	return ss, nil
 }
 
-func (s *TermSlot) Stop() {
+// CaptureIndexed is like CaptureRGB, but keeps the ANSI colors as indices.
+func (s *TermSlot) CaptureIndexed(start bool) (vterm.ScreenShot, Theme, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.startIf(start)
+	if err != nil {
+		return vterm.ScreenShot{}, Theme{}, err
+	}
+
+	ss, pal := s.term.CaptureIndexed()
+	return ss, pal, nil
+}
+
+func (s *TermSlot) Clipboard() ([]byte, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.term == nil {
-		return
+		return []byte{}, nil
 	}
 
-	err := s.term.Close()
+	return s.term.Clipboard(), nil
+}
+
+func (s *TermSlot) SetClipboard(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.term == nil {
+		return ErrNotRunning
+	}
+
+	s.term.SetClipboard(b)
+	return nil
+}
+
+func (s *TermSlot) Copy(rect vterm.Rect) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.term == nil {
+		return "", ErrNotRunning
+	}
+
+	return s.term.Copy(rect), nil
+}
+
+func (s *TermSlot) TakeSequences() ([]vterm.Sequence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.term == nil {
+		return []vterm.Sequence{}, nil
+	}
+
+	return s.term.TakeSequences(), nil
+}
+
+func (s *TermSlot) Events(since uint64) ([]Event, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, last := s.eq.Since(since)
+	return events, last, nil
+}
+
+func (s *TermSlot) SetTheme(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	theme, ok := LookupTheme(name)
+	if !ok {
+		return ErrInvalidTheme
+	}
+
+	s.cfg.Theme = name
+	if s.term != nil {
+		s.term.SetTheme(theme)
+	}
+	return nil
+}
+
+func (s *TermSlot) Signal(sig xpty.Signal, target xpty.SignalTarget) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.term == nil {
+		return ErrNotRunning
+	}
+
+	return s.term.Signal(sig, target)
+}
+
+func (s *TermSlot) Status() (TermStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.term == nil {
+		return TermStatus{}, nil
+	}
+
+	return s.term.Status()
+}
+
+// Ready checks that a terminal could be started, without starting one.
+// Only the theme and opening a pty are checked; libvterm has no failure
+// mode to probe, and the command is not run.
+func (s *TermSlot) Ready() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.term != nil {
+		return nil
+	}
+
+	if _, ok := LookupTheme(s.cfg.Theme); !ok {
+		return ErrInvalidTheme
+	}
+
+	pt, err := s.cfg.Open()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	return pt.Close()
+}
 
-	s.term = nil
+func (s *TermSlot) Stats() SlotStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := SlotStats{
+		Starts:       s.starts,
+		Restarts:     s.restarts,
+		PtyIn:        s.ptyIn,
+		PtyProcessed: s.ptyProcessed,
+		PtyChunks:    s.ptyChunks,
+		PtyOut:       s.ptyOut,
+		PtyDropped:   s.ptyDropped,
+	}
+	if s.term != nil {
+		in := s.term.InputStats()
+		st.PtyIn += in.BytesRead
+		st.PtyProcessed += in.BytesProcessed
+		st.PtyChunks += in.Chunks
+		st.PtyOut += s.term.OutputBytes()
+		st.PtyDropped += s.term.OutputDropped()
+	}
+	return st
+}
+
+func (s *TermSlot) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.term == nil {
+		return
+	}
+
+	s.stop()
 }
 
 func (s *TermSlot) Close() error {
@@ -75,11 +314,88 @@ func (s *TermSlot) start() error {
 		return nil
 	}
 
-	term, err := NewTerm(s.cfg)
+	return s.startWith(s.cfg)
+}
+
+func (s *TermSlot) startIf(start bool) error {
+	if s.term == nil && !start {
+		return ErrNotRunning
+	}
+	return s.start()
+}
+
+func (s *TermSlot) stop() {
+	err := s.term.Close()
+	if err != nil {
+		panic(err)
+	}
+	s.cfg.logger().Info("terminal stopped")
+
+	in := s.term.InputStats()
+	s.ptyIn += in.BytesRead
+	s.ptyProcessed += in.BytesProcessed
+	s.ptyChunks += in.Chunks
+	s.ptyOut += s.term.OutputBytes()
+	s.ptyDropped += s.term.OutputDropped()
+	s.term = nil
+}
+
+func (s *TermSlot) startWith(cfg TermConfig) error {
+	cfg.Events = s.eq
+	term, err := NewTerm(cfg)
 	if err != nil {
 		return err
 	}
+	cfg.logger().Info("terminal started",
+		"pid", term.pc.Pid,
+		"path", cfg.Cmd.Path,
+		"row", cfg.Row,
+		"col", cfg.Col,
+	)
 
 	s.term = term
+	s.starts++
+	if cfg.Restart != "" && cfg.Restart != RestartNever {
+		go s.watch(term, cfg)
+	}
 	return nil
 }
+
+// watch restarts term with cfg after its process exits, if the restart
+// policy asks for it and the terminal has not been stopped meanwhile.
+func (s *TermSlot) watch(term *Term, cfg TermConfig) {
+	ok := s.restart(term, cfg)
+	if cfg.Restarted != nil {
+		cfg.Restarted(ok)
+	}
+}
+
+func (s *TermSlot) restart(term *Term, cfg TermConfig) bool {
+	<-term.Done()
+	code, ok := term.ExitCode()
+	if !ok || !cfg.Restart.ShouldRestart(code) {
+		return false
+	}
+
+	delay := cfg.RestartDelay
+	if delay <= 0 {
+		delay = DefaultRestartDelay
+	}
+	time.Sleep(delay)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.term != term {
+		return false
+	}
+
+	s.stop()
+	err := s.startWith(cfg)
+	if err != nil {
+		cfg.logger().Error("failed to restart terminal", "err", err.Error())
+		return false
+	}
+	s.restarts++
+	return true
+}
@@ -6,7 +6,9 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gcrtnst/sw-term-server/internal/vterm"
 	"github.com/gcrtnst/sw-term-server/internal/xpty"
@@ -36,6 +38,46 @@ func TestTermSlotKeyboard(t *testing.T) {
 			wantMTOpenTerminal: true,
 			wantOut:            []byte("\x1B[65;7u"),
 		},
+		{
+			name:               "NameModifier",
+			inStart:            true,
+			inMTErrOpen:        errDummy,
+			inKey:              "Shift+Tab",
+			inMod:              vterm.ModNone,
+			wantErr:            nil,
+			wantMTOpenTerminal: true,
+			wantOut:            []byte("\x1B[Z"),
+		},
+		{
+			name:               "NameModifierMerge",
+			inStart:            true,
+			inMTErrOpen:        errDummy,
+			inKey:              "C-a",
+			inMod:              vterm.ModAlt,
+			wantErr:            nil,
+			wantMTOpenTerminal: true,
+			wantOut:            []byte("\x1B\x01"),
+		},
+		{
+			name:               "CtrlAt",
+			inStart:            true,
+			inMTErrOpen:        errDummy,
+			inKey:              "Ctrl-@",
+			inMod:              vterm.ModNone,
+			wantErr:            nil,
+			wantMTOpenTerminal: true,
+			wantOut:            []byte{0x00},
+		},
+		{
+			name:               "F13",
+			inStart:            true,
+			inMTErrOpen:        errDummy,
+			inKey:              "F13",
+			inMod:              vterm.ModNone,
+			wantErr:            nil,
+			wantMTOpenTerminal: true,
+			wantOut:            []byte("\x1B[1;2P"),
+		},
 		{
 			name:               "Start",
 			inStart:            false,
@@ -118,6 +160,144 @@ func TestTermSlotKeyboard(t *testing.T) {
 	}
 }
 
+func TestTermSlotMacro(t *testing.T) {
+	errDummy := errors.New("dummy error")
+	pid := os.Getpid()
+
+	tt := []struct {
+		name        string
+		inMTErrOpen error
+		inMacro     Macro
+		wantErr     bool
+		wantOut     []byte
+	}{
+		{
+			name: "Normal",
+			inMacro: Macro{
+				{Text: "ls"},
+				{Delay: time.Millisecond},
+				{Key: "Enter"},
+				{Key: "c", Mod: vterm.ModCtrl},
+			},
+			wantErr: false,
+			wantOut: []byte("ls\r\x03"),
+		},
+		{
+			name:    "Invalid",
+			inMacro: Macro{{Text: "ls"}, {Key: "Nonexistent"}},
+			wantErr: true,
+			wantOut: []byte{},
+		},
+		{
+			name:        "StartError",
+			inMTErrOpen: errDummy,
+			inMacro:     Macro{{Text: "ls"}},
+			wantErr:     true,
+			wantOut:     []byte{},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{PID: pid, ErrOpen: tc.inMTErrOpen}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+			}
+			slot := NewTermSlot(cfg)
+
+			gotErr := slot.Macro(tc.inMacro)
+			gotMTOpenTerminal := mt.OpenTerminal
+
+			if slot.term != nil {
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			gotOut := []byte{}
+			if gotMTOpenTerminal {
+				mc := mt.Computer()
+				gotOut, _ = io.ReadAll(mc)
+			}
+
+			if (gotErr != nil) != tc.wantErr {
+				t.Errorf("err: expected %t, got %#v", tc.wantErr, gotErr)
+			}
+			if !bytes.Equal(gotOut, tc.wantOut) {
+				t.Errorf("mt out: expected %#v, got %#v", tc.wantOut, gotOut)
+			}
+		})
+	}
+}
+
+func TestTermSlotMacroDelay(t *testing.T) {
+	const delay = 500 * time.Millisecond
+
+	mt := &xpty.MockTerminal{PID: os.Getpid()}
+	cfg := TermConfig{
+		Open: mt.Open,
+		Row:  30,
+		Col:  120,
+		Cmd: xpty.Cmd{
+			Path: "bash",
+			Args: []string{"--version"},
+		},
+	}
+	slot := NewTermSlot(cfg)
+
+	md := make(chan error, 1)
+	go func() {
+		md <- slot.Macro(Macro{{Text: "a"}, {Delay: delay}, {Text: "b"}})
+	}()
+
+	// Wait until the macro has sent its first step and is in its delay.
+	start := time.Now()
+	for {
+		st := slot.Stats()
+		if time.Since(start) >= delay/2 {
+			t.Fatal("stats: blocked by the macro delay")
+		}
+		if st.PtyOut >= 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start = time.Now()
+	_, err := slot.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= delay/2 {
+		t.Errorf("status: expected less than %s, got %s", delay/2, elapsed)
+	}
+
+	// Keyboard input waits for the macro, so that it is not interleaved.
+	err = slot.Keyboard("c", vterm.ModNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = <-md
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slot.term.pc = nil
+	slot.Stop()
+
+	gotOut, _ := io.ReadAll(mt.Computer())
+	wantOut := []byte("abc")
+	if !bytes.Equal(gotOut, wantOut) {
+		t.Errorf("mt out: expected %#v, got %#v", wantOut, gotOut)
+	}
+}
+
 func TestTermSlotCaptureRGB(t *testing.T) {
 	errDummy := errors.New("dummy error")
 	pid := os.Getpid()
@@ -291,7 +471,7 @@ func TestTermSlotCaptureRGB(t *testing.T) {
 			}
 
 			mt.ErrOpen = tc.inMTErrOpen
-			gotSS, gotErr := slot.CaptureRGB()
+			gotSS, gotErr := slot.CaptureRGB(true)
 			gotMTOpenTerminal := mt.OpenTerminal
 
 			if slot.term != nil {
@@ -312,6 +492,111 @@ func TestTermSlotCaptureRGB(t *testing.T) {
 	}
 }
 
+func TestTermSlotSetTheme(t *testing.T) {
+	pid := os.Getpid()
+	gruvbox, _ := LookupTheme("gruvbox")
+
+	tt := []struct {
+		name      string
+		inStart   bool
+		inName    string
+		wantErr   error
+		wantTheme string
+		wantFG    vterm.Color
+		wantBG    vterm.Color
+	}{
+		{
+			name:      "Normal",
+			inStart:   true,
+			inName:    "gruvbox",
+			wantErr:   nil,
+			wantTheme: "gruvbox",
+			wantFG:    gruvbox.Palette[1],
+			wantBG:    gruvbox.BG,
+		},
+		{
+			name:      "NotRunning",
+			inStart:   false,
+			inName:    "gruvbox",
+			wantErr:   nil,
+			wantTheme: "gruvbox",
+		},
+		{
+			name:      "Invalid",
+			inStart:   true,
+			inName:    "unknown",
+			wantErr:   ErrInvalidTheme,
+			wantTheme: "",
+			wantFG:    vterm.NewColorRGB(0xC4, 0x40, 0x40),
+			wantBG:    vterm.NewColorRGB(0x00, 0x00, 0x00),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{PID: pid}
+			mc := mt.Computer()
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  1,
+				Col:  1,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+			}
+			slot := NewTermSlot(cfg)
+
+			if tc.inStart {
+				var err error
+
+				err = slot.start()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				_, err = mc.Write([]byte("\x1B[31mA"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				_, err = mc.Write([]byte{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			gotErr := slot.SetTheme(tc.inName)
+			gotTheme := slot.cfg.Theme
+			gotRunning := slot.term != nil
+
+			var gotCell vterm.Cell
+			if gotRunning {
+				gotCell = slot.term.CaptureRGB().At(vterm.Pos{Row: 0, Col: 0})
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			if gotErr != tc.wantErr {
+				t.Errorf("err: expected %#v, got %#v", tc.wantErr, gotErr)
+			}
+			if gotTheme != tc.wantTheme {
+				t.Errorf("theme: expected %q, got %q", tc.wantTheme, gotTheme)
+			}
+			if gotRunning != tc.inStart {
+				t.Errorf("running: expected %t, got %t", tc.inStart, gotRunning)
+			}
+			if gotRunning && !reflect.DeepEqual(gotCell.FG, tc.wantFG) {
+				t.Errorf("fg: expected %#v, got %#v", tc.wantFG, gotCell.FG)
+			}
+			if gotRunning && !reflect.DeepEqual(gotCell.BG, tc.wantBG) {
+				t.Errorf("bg: expected %#v, got %#v", tc.wantBG, gotCell.BG)
+			}
+		})
+	}
+}
+
 func TestTermSlotStop(t *testing.T) {
 	pid := os.Getpid()
 	mt := &xpty.MockTerminal{PID: pid}
@@ -474,3 +759,303 @@ func TestTermSlotStart(t *testing.T) {
 		})
 	}
 }
+
+func TestTermSlotStats(t *testing.T) {
+	mt := &xpty.MockTerminal{PID: os.Getpid()}
+	cfg := TermConfig{
+		Open: mt.Open,
+		Row:  30,
+		Col:  120,
+		Cmd: xpty.Cmd{
+			Path: "bash",
+			Args: []string{"--version"},
+		},
+	}
+	slot := NewTermSlot(cfg)
+
+	for i := 0; i < 2; i++ {
+		err := slot.start()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mc := mt.Computer()
+		_, err = mc.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = mc.Write([]byte{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		slot.term.pc = nil
+		slot.Stop()
+	}
+
+	got := slot.Stats()
+	want := SlotStats{Starts: 2, PtyIn: 10, PtyProcessed: 10, PtyChunks: 2, PtyOut: 0}
+	if got != want {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+}
+
+func TestTermSlotEvents(t *testing.T) {
+	mt := &xpty.MockTerminal{}
+	cfg := TermConfig{
+		Open: mt.Open,
+		Row:  30,
+		Col:  120,
+		Cmd: xpty.Cmd{
+			Path: "sh",
+			Args: []string{"sh"},
+		},
+	}
+	slot := NewTermSlot(cfg)
+
+	var since uint64
+	for i, code := range []int{0, 1} {
+		mt.PID = startExiting(t, code)
+		err := slot.start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-slot.term.Done()
+		slot.Stop()
+
+		events, last, err := slot.Events(since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []Event{{Seq: uint64(i + 1), Type: EventExit, Data: strconv.Itoa(code)}}
+		if !reflect.DeepEqual(events, want) {
+			t.Errorf("events %d: expected %#v, got %#v", i, want, events)
+		}
+		if last != uint64(i+1) {
+			t.Errorf("last %d: expected %d, got %d", i, i+1, last)
+		}
+		since = last
+	}
+}
+
+func TestTermSlotStartProfile(t *testing.T) {
+	pid := os.Getpid()
+	profiles := map[string]Profile{
+		"shell": {Cmd: xpty.Cmd{Path: "bash", Args: []string{"bash"}}},
+		"htop":  {Cmd: xpty.Cmd{Path: "htop", Args: []string{"htop"}}},
+	}
+
+	tt := []struct {
+		name      string
+		inRunning bool
+		inExited  bool
+		inProfile string
+		inRow     int
+		inCol     int
+		wantErr   error
+		wantCmd   xpty.Cmd
+		wantSize  xpty.Size
+	}{
+		{
+			name:      "Normal",
+			inProfile: "htop",
+			wantErr:   nil,
+			wantCmd:   xpty.Cmd{Path: "htop", Args: []string{"htop"}},
+			wantSize:  xpty.Size{Row: 30, Col: 120},
+		},
+		{
+			name:      "Size",
+			inProfile: "shell",
+			inRow:     10,
+			inCol:     20,
+			wantErr:   nil,
+			wantCmd:   xpty.Cmd{Path: "bash", Args: []string{"bash"}},
+			wantSize:  xpty.Size{Row: 10, Col: 20},
+		},
+		{
+			name:      "Unknown",
+			inProfile: "rm",
+			wantErr:   ErrUnknownProfile,
+		},
+		{
+			name:      "Running",
+			inRunning: true,
+			inProfile: "htop",
+			wantErr:   ErrAlreadyRunning,
+		},
+		{
+			name:      "Exited",
+			inRunning: true,
+			inExited:  true,
+			inProfile: "htop",
+			wantErr:   nil,
+			wantCmd:   xpty.Cmd{Path: "htop", Args: []string{"htop"}},
+			wantSize:  xpty.Size{Row: 30, Col: 120},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &xpty.MockTerminal{PID: pid}
+			cfg := TermConfig{
+				Open: mt.Open,
+				Row:  30,
+				Col:  120,
+				Cmd: xpty.Cmd{
+					Path: "bash",
+					Args: []string{"--version"},
+				},
+				Profiles: profiles,
+			}
+			slot := NewTermSlot(cfg)
+
+			if tc.inExited {
+				mt.PID = startExiting(t, 0)
+			}
+			if tc.inRunning {
+				err := slot.start()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.inExited {
+				<-slot.term.Done()
+			}
+			before := slot.term
+
+			gotErr := slot.Start(tc.inProfile, tc.inRow, tc.inCol)
+			gotTerm := slot.term
+
+			if slot.term != nil {
+				slot.term.pc = nil
+			}
+			slot.Stop()
+
+			if gotErr != tc.wantErr {
+				t.Fatalf("err: expected %#v, got %#v", tc.wantErr, gotErr)
+			}
+			if gotErr != nil {
+				if gotTerm != before {
+					t.Errorf("slot.term changed on error")
+				}
+				return
+			}
+			if gotTerm == before {
+				t.Errorf("slot.term not replaced")
+			}
+			if !reflect.DeepEqual(mt.Cmd, tc.wantCmd) {
+				t.Errorf("cmd: expected %#v, got %#v", tc.wantCmd, mt.Cmd)
+			}
+			if mt.Size != tc.wantSize {
+				t.Errorf("size: expected %#v, got %#v", tc.wantSize, mt.Size)
+			}
+		})
+	}
+}
+
+func TestTermSlotRestart(t *testing.T) {
+	tt := []struct {
+		name          string
+		inRestart     RestartPolicy
+		inCode        int
+		inStop        bool
+		wantRestarted []bool
+		wantStarts    uint64
+		wantRestarts  uint64
+		wantOpen      bool
+	}{
+		{
+			name:          "OnFailure",
+			inRestart:     RestartOnFailure,
+			inCode:        1,
+			wantRestarted: []bool{true, false},
+			wantStarts:    2,
+			wantRestarts:  1,
+			wantOpen:      true,
+		},
+		{
+			name:          "OnFailureSuccess",
+			inRestart:     RestartOnFailure,
+			inCode:        0,
+			wantRestarted: []bool{false},
+			wantStarts:    1,
+			wantRestarts:  0,
+			wantOpen:      true,
+		},
+		{
+			name:          "Stopped",
+			inRestart:     RestartAlways,
+			inCode:        1,
+			inStop:        true,
+			wantRestarted: []bool{false},
+			wantStarts:    1,
+			wantRestarts:  0,
+			wantOpen:      false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// A restarted terminal gets a process exiting successfully, so
+			// that it is not restarted again.
+			pids := []int{startExiting(t, tc.inCode), startExiting(t, 0)}
+			mt := &xpty.MockTerminal{}
+			restarted := make(chan bool, len(pids))
+			cfg := TermConfig{
+				Open: func() (xpty.Terminal, error) {
+					mt.PID, pids = pids[0], pids[1:]
+					return mt.Open()
+				},
+				Row: 30,
+				Col: 120,
+				Cmd: xpty.Cmd{
+					Path: "sh",
+					Args: []string{"sh"},
+				},
+				Restart:      tc.inRestart,
+				RestartDelay: time.Millisecond,
+				Restarted:    func(ok bool) { restarted <- ok },
+			}
+			slot := NewTermSlot(cfg)
+
+			err := slot.start()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.inStop {
+				slot.Stop()
+			}
+
+			gotRestarted := []bool{}
+			for len(gotRestarted) < len(tc.wantRestarted) {
+				ok := <-restarted
+				gotRestarted = append(gotRestarted, ok)
+				if !ok {
+					break
+				}
+			}
+
+			got := slot.Stats()
+			gotOpen := mt.OpenTerminal
+			slot.Stop()
+
+			if !reflect.DeepEqual(gotRestarted, tc.wantRestarted) {
+				t.Errorf("restarted: expected %#v, got %#v", tc.wantRestarted, gotRestarted)
+			}
+			if got.Starts != tc.wantStarts {
+				t.Errorf("starts: expected %d, got %d", tc.wantStarts, got.Starts)
+			}
+			if got.Restarts != tc.wantRestarts {
+				t.Errorf("restarts: expected %d, got %d", tc.wantRestarts, got.Restarts)
+			}
+			if gotOpen != tc.wantOpen {
+				t.Errorf("open: expected %t, got %t", tc.wantOpen, gotOpen)
+			}
+			if mt.OpenTerminal {
+				t.Errorf("terminal open")
+			}
+		})
+	}
+}
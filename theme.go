@@ -0,0 +1,136 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/gcrtnst/sw-term-server/internal/vterm"
+)
+
+const DefaultThemeName = "default"
+
+type Theme struct {
+	FG, BG  vterm.Color
+	Palette [16]vterm.Color
+}
+
+func (th Theme) Apply(scr *vterm.Screen) {
+	scr.SetDefaultColor(th.FG, th.BG)
+	for i, col := range th.Palette {
+		scr.SetPaletteColor(byte(i), col)
+	}
+}
+
+var themes = map[string]Theme{
+	"default": {
+		FG: themeColor(0xC4C4C4),
+		BG: themeColor(0x000000),
+		Palette: [16]vterm.Color{
+			themeColor(0x000000),
+			themeColor(0xC44040),
+			themeColor(0x40C440),
+			themeColor(0xC4C440),
+			themeColor(0x4040C4),
+			themeColor(0xC440C4),
+			themeColor(0x40C4C4),
+			themeColor(0xC4C4C4),
+			themeColor(0x606060),
+			themeColor(0xFF6060),
+			themeColor(0x60FF60),
+			themeColor(0xFFFF60),
+			themeColor(0x6060FF),
+			themeColor(0xFF60FF),
+			themeColor(0x60FFFF),
+			themeColor(0xFFFFFF),
+		},
+	},
+	"solarized": {
+		FG: themeColor(0x839496),
+		BG: themeColor(0x002B36),
+		Palette: [16]vterm.Color{
+			themeColor(0x073642),
+			themeColor(0xDC322F),
+			themeColor(0x859900),
+			themeColor(0xB58900),
+			themeColor(0x268BD2),
+			themeColor(0xD33682),
+			themeColor(0x2AA198),
+			themeColor(0xEEE8D5),
+			themeColor(0x002B36),
+			themeColor(0xCB4B16),
+			themeColor(0x586E75),
+			themeColor(0x657B83),
+			themeColor(0x839496),
+			themeColor(0x6C71C4),
+			themeColor(0x93A1A1),
+			themeColor(0xFDF6E3),
+		},
+	},
+	"gruvbox": {
+		FG: themeColor(0xEBDBB2),
+		BG: themeColor(0x282828),
+		Palette: [16]vterm.Color{
+			themeColor(0x282828),
+			themeColor(0xCC241D),
+			themeColor(0x98971A),
+			themeColor(0xD79921),
+			themeColor(0x458588),
+			themeColor(0xB16286),
+			themeColor(0x689D6A),
+			themeColor(0xA89984),
+			themeColor(0x928374),
+			themeColor(0xFB4934),
+			themeColor(0xB8BB26),
+			themeColor(0xFABD2F),
+			themeColor(0x83A598),
+			themeColor(0xD3869B),
+			themeColor(0x8EC07C),
+			themeColor(0xEBDBB2),
+		},
+	},
+
+	// Saturated colors that stay distinguishable on the low-resolution
+	// monitors in Stormworks.
+	"high-contrast": {
+		FG: themeColor(0xFFFFFF),
+		BG: themeColor(0x000000),
+		Palette: [16]vterm.Color{
+			themeColor(0x000000),
+			themeColor(0xFF3030),
+			themeColor(0x30FF30),
+			themeColor(0xFFFF00),
+			themeColor(0x4080FF),
+			themeColor(0xFF40FF),
+			themeColor(0x00FFFF),
+			themeColor(0xE0E0E0),
+			themeColor(0x808080),
+			themeColor(0xFF8080),
+			themeColor(0x80FF80),
+			themeColor(0xFFFF80),
+			themeColor(0x80C0FF),
+			themeColor(0xFF80FF),
+			themeColor(0x80FFFF),
+			themeColor(0xFFFFFF),
+		},
+	},
+}
+
+func LookupTheme(name string) (Theme, bool) {
+	if name == "" {
+		name = DefaultThemeName
+	}
+	th, ok := themes[name]
+	return th, ok
+}
+
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func themeColor(rgb uint32) vterm.Color {
+	return vterm.NewColorRGB(uint8(rgb>>16), uint8(rgb>>8), uint8(rgb))
+}
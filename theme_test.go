@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestLookupTheme(t *testing.T) {
+	tt := []struct {
+		name   string
+		inName string
+		wantOK bool
+	}{
+		{
+			name:   "Empty",
+			inName: "",
+			wantOK: true,
+		},
+		{
+			name:   "Default",
+			inName: "default",
+			wantOK: true,
+		},
+		{
+			name:   "Solarized",
+			inName: "solarized",
+			wantOK: true,
+		},
+		{
+			name:   "Gruvbox",
+			inName: "gruvbox",
+			wantOK: true,
+		},
+		{
+			name:   "HighContrast",
+			inName: "high-contrast",
+			wantOK: true,
+		},
+		{
+			name:   "Unknown",
+			inName: "unknown",
+			wantOK: false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			gotTheme, gotOK := LookupTheme(tc.inName)
+			if gotOK != tc.wantOK {
+				t.Fatalf("ok: expected %t, got %t", tc.wantOK, gotOK)
+			}
+			if !gotOK {
+				return
+			}
+
+			if !gotTheme.FG.IsRGB() {
+				t.Errorf("fg: expected rgb, got %#v", gotTheme.FG)
+			}
+			if !gotTheme.BG.IsRGB() {
+				t.Errorf("bg: expected rgb, got %#v", gotTheme.BG)
+			}
+			for i, col := range gotTheme.Palette {
+				if !col.IsRGB() {
+					t.Errorf("palette %d: expected rgb, got %#v", i, col)
+				}
+			}
+		})
+	}
+}
+
+func TestThemeNames(t *testing.T) {
+	got := ThemeNames()
+	if len(got) != len(themes) {
+		t.Fatalf("len: expected %d, got %d", len(themes), len(got))
+	}
+	for i, name := range got {
+		if _, ok := themes[name]; !ok {
+			t.Errorf("names[%d]: unknown theme %q", i, name)
+		}
+		if i > 0 && got[i-1] >= name {
+			t.Errorf("names[%d]: %q is not sorted after %q", i, name, got[i-1])
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultThrottleChunkSize = 4096
+
+// ThrottleConfig controls how pty output is fed to vterm. Rate limits the
+// bytes processed per second (0 disables the limit), and Coalesce delays
+// processing to gather bursts of small reads into a single chunk.
+type ThrottleConfig struct {
+	ChunkSize int
+	Rate      int
+	Coalesce  time.Duration
+}
+
+type ThrottleStats struct {
+	BytesRead      uint64
+	BytesProcessed uint64
+	Chunks         uint64
+}
+
+// Throttle copies pty output to vterm in chunks of at most ChunkSize
+// bytes, yielding between chunks so that the vterm lock is not held for
+// long under heavy output.
+type Throttle struct {
+	cfg  ThrottleConfig
+	stop chan struct{}
+	so   sync.Once
+
+	read      atomic.Uint64
+	processed atomic.Uint64
+	chunks    atomic.Uint64
+}
+
+func NewThrottle(cfg ThrottleConfig) *Throttle {
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = DefaultThrottleChunkSize
+	}
+	return &Throttle{cfg: cfg, stop: make(chan struct{})}
+}
+
+// Stop cuts short any pending rate limit wait. Copy keeps processing the
+// remaining output without the rate limit until src returns an error.
+func (th *Throttle) Stop() {
+	th.so.Do(func() { close(th.stop) })
+}
+
+func (th *Throttle) Stats() ThrottleStats {
+	return ThrottleStats{
+		BytesRead:      th.read.Load(),
+		BytesProcessed: th.processed.Load(),
+		Chunks:         th.chunks.Load(),
+	}
+}
+
+// Copy copies from src to dst until src returns an error. Like io.Copy, it
+// returns nil if src reaches EOF.
+//
+// Without Coalesce, src is read only after the previous read has been
+// written to dst, so pty backpressure applies as with io.Copy.
+func (th *Throttle) Copy(dst io.Writer, src io.Reader) error {
+	if th.cfg.Coalesce <= 0 {
+		return th.copyDirect(dst, src)
+	}
+
+	rc := make(chan []byte)
+	ec := make(chan error, 1)
+	go func() {
+		for {
+			buf := make([]byte, th.cfg.ChunkSize)
+			n, err := src.Read(buf)
+			if n > 0 {
+				th.read.Add(uint64(n))
+				rc <- buf[:n]
+			}
+			if err != nil {
+				ec <- err
+				close(rc)
+				return
+			}
+		}
+	}()
+
+	var next time.Time
+	for p := range rc {
+		p = th.coalesce(p, rc)
+
+		var err error
+		next, err = th.write(dst, p, next)
+		if err != nil {
+			go drain(rc)
+			return err
+		}
+	}
+
+	err := <-ec
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+func (th *Throttle) copyDirect(dst io.Writer, src io.Reader) error {
+	buf := make([]byte, th.cfg.ChunkSize)
+	var next time.Time
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			th.read.Add(uint64(n))
+
+			var werr error
+			next, werr = th.write(dst, buf[:n], next)
+			if werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (th *Throttle) write(dst io.Writer, p []byte, next time.Time) (time.Time, error) {
+	for len(p) > 0 {
+		n := len(p)
+		if n > th.cfg.ChunkSize {
+			n = th.cfg.ChunkSize
+		}
+
+		_, err := dst.Write(p[:n])
+		if err != nil {
+			return next, err
+		}
+		th.processed.Add(uint64(n))
+		th.chunks.Add(1)
+		p = p[n:]
+
+		next = th.wait(next, n)
+		runtime.Gosched()
+	}
+	return next, nil
+}
+
+func (th *Throttle) coalesce(p []byte, rc <-chan []byte) []byte {
+	timer := time.NewTimer(th.cfg.Coalesce)
+	defer timer.Stop()
+
+	for len(p) < th.cfg.ChunkSize {
+		select {
+		case q, ok := <-rc:
+			if !ok {
+				return p
+			}
+			p = append(p, q...)
+		case <-timer.C:
+			return p
+		}
+	}
+	return p
+}
+
+// wait sleeps until n more bytes are allowed by the rate limit. next is the
+// earliest time the previous chunk allowed processing to resume.
+func (th *Throttle) wait(next time.Time, n int) time.Time {
+	if th.cfg.Rate <= 0 {
+		return next
+	}
+
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	next = next.Add(time.Duration(n) * time.Second / time.Duration(th.cfg.Rate))
+
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-th.stop:
+	}
+	return next
+}
+
+func drain(rc <-chan []byte) {
+	for range rc {
+	}
+}
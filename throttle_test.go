@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type recordWriter struct {
+	buf    bytes.Buffer
+	writes []int
+	err    error
+}
+
+func (w *recordWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.writes = append(w.writes, len(p))
+	return w.buf.Write(p)
+}
+
+func TestThrottleCopy(t *testing.T) {
+	tt := []struct {
+		name       string
+		inCfg      ThrottleConfig
+		inSrc      []byte
+		wantWrites []int
+		wantStats  ThrottleStats
+	}{
+		{
+			name:       "Empty",
+			inCfg:      ThrottleConfig{ChunkSize: 4},
+			inSrc:      []byte{},
+			wantWrites: nil,
+			wantStats:  ThrottleStats{},
+		},
+		{
+			name:       "Small",
+			inCfg:      ThrottleConfig{ChunkSize: 4},
+			inSrc:      []byte("abc"),
+			wantWrites: []int{3},
+			wantStats:  ThrottleStats{BytesRead: 3, BytesProcessed: 3, Chunks: 1},
+		},
+		{
+			name:       "Chunked",
+			inCfg:      ThrottleConfig{ChunkSize: 4},
+			inSrc:      []byte("abcdefghij"),
+			wantWrites: []int{4, 4, 2},
+			wantStats:  ThrottleStats{BytesRead: 10, BytesProcessed: 10, Chunks: 3},
+		},
+		{
+			name:       "DefaultChunkSize",
+			inCfg:      ThrottleConfig{},
+			inSrc:      make([]byte, DefaultThrottleChunkSize+1),
+			wantWrites: []int{DefaultThrottleChunkSize, 1},
+			wantStats:  ThrottleStats{BytesRead: DefaultThrottleChunkSize + 1, BytesProcessed: DefaultThrottleChunkSize + 1, Chunks: 2},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			th := NewThrottle(tc.inCfg)
+			w := &recordWriter{}
+
+			err := th.Copy(w, bytes.NewReader(tc.inSrc))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(w.buf.Bytes(), tc.inSrc) {
+				t.Errorf("data: expected %#v, got %#v", tc.inSrc, w.buf.Bytes())
+			}
+			if !reflect.DeepEqual(w.writes, tc.wantWrites) {
+				t.Errorf("writes: expected %#v, got %#v", tc.wantWrites, w.writes)
+			}
+			if gotStats := th.Stats(); gotStats != tc.wantStats {
+				t.Errorf("stats: expected %#v, got %#v", tc.wantStats, gotStats)
+			}
+		})
+	}
+}
+
+func TestThrottleCopyCoalesce(t *testing.T) {
+	pr, pw := io.Pipe()
+	go func() {
+		for _, s := range []string{"a", "b", "c"} {
+			_, _ = pw.Write([]byte(s))
+		}
+		time.Sleep(200 * time.Millisecond)
+		_, _ = pw.Write([]byte("d"))
+		_ = pw.Close()
+	}()
+
+	th := NewThrottle(ThrottleConfig{ChunkSize: 16, Coalesce: 100 * time.Millisecond})
+	w := &recordWriter{}
+	err := th.Copy(w, pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantWrites := []int{3, 1}
+	if !reflect.DeepEqual(w.writes, wantWrites) {
+		t.Errorf("writes: expected %#v, got %#v", wantWrites, w.writes)
+	}
+	if got := w.buf.String(); got != "abcd" {
+		t.Errorf("data: expected %#v, got %#v", "abcd", got)
+	}
+}
+
+func TestThrottleCopyRate(t *testing.T) {
+	th := NewThrottle(ThrottleConfig{ChunkSize: 100, Rate: 1000})
+	w := &recordWriter{}
+
+	start := time.Now()
+	err := th.Copy(w, bytes.NewReader(make([]byte, 300)))
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed < 250*time.Millisecond {
+		t.Errorf("elapsed: expected at least 250ms, got %s", elapsed)
+	}
+}
+
+func TestThrottleStop(t *testing.T) {
+	th := NewThrottle(ThrottleConfig{ChunkSize: 10, Rate: 10})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		th.Stop()
+	}()
+
+	start := time.Now()
+	err := th.Copy(&recordWriter{}, bytes.NewReader(make([]byte, 30)))
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed >= time.Second {
+		t.Errorf("elapsed: expected less than 1s, got %s", elapsed)
+	}
+}
+
+func TestThrottleCopyError(t *testing.T) {
+	errWrite := errors.New("write error")
+	errRead := errors.New("read error")
+
+	th := NewThrottle(ThrottleConfig{})
+	err := th.Copy(&recordWriter{err: errWrite}, bytes.NewReader([]byte("abc")))
+	if !errors.Is(err, errWrite) {
+		t.Errorf("write: expected %#v, got %#v", errWrite, err)
+	}
+
+	pr, pw := io.Pipe()
+	_ = pw.CloseWithError(errRead)
+	err = th.Copy(&recordWriter{}, pr)
+	if !errors.Is(err, errRead) {
+		t.Errorf("read: expected %#v, got %#v", errRead, err)
+	}
+}